@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/makyo/mandelnote/export"
+	"github.com/makyo/mandelnote/notebook"
+)
+
+var (
+	exportFormat   string
+	exportOutput   string
+	exportMinDepth int
+	exportMaxDepth int
+	exportSettings []string
+)
+
+var exportCommand = &cobra.Command{
+	Use:   "export <note file>",
+	Short: "Export a notebook to another format",
+	Long: `Export a notebook to another format
+
+Writes the notebook in the chosen format to the output file, or to standard
+output if there is none. The export may be limited to cards between a minimum
+and maximum depth, where top-level cards are at depth 1; cards above the
+minimum are left out, but their children are kept. Some formats take further
+settings, given as --set key=value.
+
+Formats:
+
+  epub          an EPUB 3 ebook, with the notebook's title, author and
+                description, with these settings:
+                  chapter     the depth of the cards which start chapters,
+                              by default the shallowest exported
+                  cover       the path to a JPEG, PNG, GIF or SVG image to
+                              use as the cover, by default the cover given
+                              in the notebook's front matter
+                  language    the language of the book, by default en
+  html          a single page with a table of contents, needing nothing else
+                to be read
+  latex         a LaTeX document using the notebook's title and author, with
+                these settings:
+                  class       the document class, by default article
+                  sections    the sectioning commands for each level of
+                              cards, such as "chapter,section,subsection";
+                              by default, classes with chapters such as
+                              book and report start at part, and others at
+                              section
+                  abstract    yes or no, whether to put the description in
+                              an abstract, which by default only article and
+                              report do
+  markdown      the notebook as it would be saved
+  manuscript    only the bodies of cards, leaving out the outline, with these
+                settings:
+                  heading.N   turns cards at depth N into headings, such as
+                              "Chapter {n}" or "Part {I}"; {n}, {I} and {i}
+                              are the number of the heading and {title} is
+                              the card's title
+                  separator   goes between scenes instead of "* * *"
+                  exclude     leaves out cards with titles matching this
+                              regular expression, and their children
+
+For example:
+
+  mandelnote export -f manuscript --min-depth 3 --set 'heading.2=Chapter {n}' novel.md`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		exporter, err := export.Get(exportFormat)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error exporting notebook: %v; must be one of %s", err,
+				strings.Join(export.Names(), ", "))
+			os.Exit(1)
+			return
+		}
+		opts := export.Options{
+			MinDepth: exportMinDepth,
+			MaxDepth: exportMaxDepth,
+			Settings: map[string]string{},
+		}
+		for _, setting := range exportSettings {
+			parts := strings.SplitN(setting, "=", 2)
+			if len(parts) != 2 {
+				fmt.Fprintf(os.Stderr, "error exporting notebook: setting %s must be of the form key=value", setting)
+				os.Exit(1)
+				return
+			}
+			opts.Settings[parts[0]] = parts[1]
+		}
+		nb, err := openExisting(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error opening notebook: %v", err)
+			os.Exit(1)
+			return
+		}
+		if err = exportTo(exportOutput, exporter, nb, opts); err != nil {
+			fmt.Fprintf(os.Stderr, "error exporting notebook: %v", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// exportTo writes the export to the named file, or to standard output if there is no name. A file which can't be
+// written completely is removed.
+func exportTo(filename string, exporter export.Exporter, nb *notebook.Notebook, opts export.Options) error {
+	if filename == "" {
+		return exporter.Export(os.Stdout, nb, opts)
+	}
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err = exporter.Export(f, nb, opts); err != nil {
+		f.Close()
+		os.Remove(filename)
+		return err
+	}
+	return f.Close()
+}
+
+func init() {
+	exportCommand.Flags().StringVarP(&exportFormat, "format", "f", "markdown", "format to export to, one of "+strings.Join(export.Names(), ", "))
+	exportCommand.Flags().StringVarP(&exportOutput, "output", "o", "", "file to write the export to, instead of standard output")
+	exportCommand.Flags().IntVar(&exportMinDepth, "min-depth", 1, "depth of the shallowest cards to export")
+	exportCommand.Flags().IntVar(&exportMaxDepth, "max-depth", 0, "depth of the deepest cards to export, or 0 for all")
+	exportCommand.Flags().StringArrayVar(&exportSettings, "set", nil, "setting for the format, as key=value; may be repeated")
+	rootCommand.AddCommand(exportCommand)
+}
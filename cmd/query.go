@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/makyo/mandelnote/notebook"
+)
+
+var queryFormat string
+
+var queryCommand = &cobra.Command{
+	Use:   "query <note file> <query>",
+	Short: "Print the cards matching a query",
+	Long: `Print the cards matching a query
+
+Prints each card in a notebook which matches the query, without its children,
+as Markdown or JSON. A query is made up of terms separated by spaces, all of
+which must match, and any of which may be negated with a leading !:
+
+  depth:N        the card is N levels deep, starting from 1
+  position:N     the card is the Nth among its siblings
+  children:N     the card has N children
+  haschildren:   yes or no
+  title:T        the title contains T
+  body:T         the body contains T
+  tag:T          the card is tagged T
+  status:S       the card has the status S
+  prop.key:V     the card has the property key set to V, or to anything if V
+                 is left empty; keys with spaces are quoted, as in
+                 prop."point of view":Ada
+  T              the title or body contains T
+
+Numbers may be compared, as in depth:<3, children:>=2 or position:2-4. Text may
+be a single word or a "quoted phrase", matched regardless of case, or a
+/regular expression/. For example:
+
+  mandelnote query novel.md 'depth:3 haschildren:no title:/^Scene/ "harbor"'`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		q, err := notebook.ParseQuery(args[1])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error reading query: %v", err)
+			os.Exit(1)
+			return
+		}
+		nb, err := openExisting(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error opening notebook: %v", err)
+			os.Exit(1)
+			return
+		}
+		cards := nb.CardsMatching(q)
+		switch queryFormat {
+		case "markdown":
+			writeMarkdown(os.Stdout, cards)
+		case "json":
+			err = writeJSON(os.Stdout, cards)
+		default:
+			err = fmt.Errorf("unknown format %s; must be markdown or json", queryFormat)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error printing cards: %v", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// queryResult is how a card is printed as JSON.
+type queryResult struct {
+	ID         string            `json:"id"`
+	Title      string            `json:"title"`
+	Body       string            `json:"body"`
+	Depth      int               `json:"depth"`
+	Position   int               `json:"position"`
+	Children   int               `json:"children"`
+	Status     string            `json:"status,omitempty"`
+	Tags       []string          `json:"tags,omitempty"`
+	Properties map[string]string `json:"properties,omitempty"`
+}
+
+func writeMarkdown(w io.Writer, cards []notebook.Card) {
+	for i, c := range cards {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "%s %s\n", strings.Repeat("#", c.Depth), c.Title)
+		if c.Body != "" {
+			fmt.Fprintf(w, "\n%s\n", c.Body)
+		}
+	}
+}
+
+func writeJSON(w io.Writer, cards []notebook.Card) error {
+	results := []queryResult{}
+	for _, c := range cards {
+		result := queryResult{
+			ID:       c.ID,
+			Title:    c.Title,
+			Body:     c.Body,
+			Depth:    c.Depth,
+			Position: c.Position,
+			Children: len(c.Children),
+			Status:   c.Status,
+			Tags:     c.Tags,
+		}
+		if len(c.Properties) > 0 {
+			result.Properties = map[string]string{}
+			for _, prop := range c.Properties {
+				result.Properties[prop.Key] = prop.Value
+			}
+		}
+		results = append(results, result)
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(results)
+}
+
+func init() {
+	queryCommand.Flags().StringVar(&queryFormat, "format", "markdown", "how to print the cards, either markdown or json")
+	rootCommand.AddCommand(queryCommand)
+}
@@ -3,6 +3,7 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -10,6 +11,12 @@ import (
 	"github.com/makyo/mandelnote/ui"
 )
 
+var (
+	undoDepth int
+	backups   int
+	autosave  time.Duration
+)
+
 var rootCommand = &cobra.Command{
 	Use:   "mandelnote <note file>",
 	Short: "Run mandelnote",
@@ -28,12 +35,30 @@ into a paper or story.`,
 			os.Exit(1)
 			return
 		}
+		nb.SetUndoDepth(undoDepth)
+		nb.SetBackups(backups)
 		tui := ui.New(nb)
+		tui.SetAutosave(autosave)
 		tui.Run()
 	},
 	Version: "0.0.1",
 }
 
+func init() {
+	rootCommand.Flags().IntVar(&undoDepth, "undo-depth", notebook.DefaultUndoDepth, "number of changes that can be undone")
+	rootCommand.Flags().IntVar(&backups, "backups", 0, "number of previous versions to keep as <note file>~1, ~2, etc. when saving")
+	rootCommand.Flags().DurationVar(&autosave, "autosave", ui.DefaultAutosave, "how often to write unsaved changes to a recovery file, or 0 to turn off")
+}
+
+// openExisting opens a notebook which must already exist. Only the editor creates a new notebook when there is no file,
+// so that other commands don't quietly work on an empty one when the file's name is mistyped.
+func openExisting(filename string) (*notebook.Notebook, error) {
+	if _, err := os.Stat(filename); err != nil {
+		return nil, err
+	}
+	return notebook.Open(filename)
+}
+
 func Execute() {
 	if err := rootCommand.Execute(); err != nil {
 		fmt.Fprintf(os.Stderr, "Yike: %v", err)
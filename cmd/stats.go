@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/makyo/mandelnote/notebook"
+)
+
+var statsCommand = &cobra.Command{
+	Use:   "stats <note file>",
+	Short: "Print word counts and progress towards targets",
+	Long: `Print word counts and progress towards targets
+
+Prints a report of the number of words in each card of a notebook, including
+those of the cards below it, alongside its target and how far along it is.
+Cards without a target of their own take the total of their children's.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		nb, err := openExisting(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error opening notebook: %v", err)
+			os.Exit(1)
+			return
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "Card\tWords\tTarget\tProgress")
+		writeStats(w, nb.Title, nb.Counts().Words, nb.Target(), 0)
+		for _, c := range nb.GetTree() {
+			writeCardStats(w, c, 1)
+		}
+		w.Flush()
+	},
+}
+
+func writeStats(w io.Writer, title string, words, target, depth int) {
+	progress := "-"
+	targetText := "-"
+	if target > 0 {
+		progress = fmt.Sprintf("%d%%", notebook.Progress(words, target))
+		targetText = fmt.Sprint(target)
+	}
+	fmt.Fprintf(w, "%s%s\t%d\t%s\t%s\n", strings.Repeat("  ", depth), title, words, targetText, progress)
+}
+
+func writeCardStats(w io.Writer, c notebook.Card, depth int) {
+	writeStats(w, c.Title, c.Total.Words, c.TotalTarget, depth)
+	for _, child := range c.Children {
+		writeCardStats(w, child, depth+1)
+	}
+}
+
+func init() {
+	rootCommand.AddCommand(statsCommand)
+}
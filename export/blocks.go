@@ -0,0 +1,278 @@
+package export
+
+import (
+	"strconv"
+	"strings"
+
+	md "github.com/makyo/mandelnote/internal/markdown"
+)
+
+// blockKind is the kind of a block of Markdown within a card's body. Headers never appear in bodies, since they start
+// new cards, so they are read as paragraphs.
+type blockKind int
+
+const (
+	paragraphBlock blockKind = iota
+	codeBlock
+	quoteBlock
+	listBlock
+	itemBlock
+	ruleBlock
+)
+
+// block is a block of Markdown. Paragraphs hold their text to be read for inline markup, code blocks their contents
+// and language, and quotes, lists and list items the blocks within them.
+type block struct {
+	kind     blockKind
+	text     string
+	language string
+	ordered  bool
+	start    int
+	tight    bool
+	children []*block
+}
+
+// indentation returns the width of the whitespace at the start of the line.
+func indentation(line string) int {
+	width, _ := md.Indentation(line)
+	return width
+}
+
+// dedent removes up to width columns of whitespace from the start of the line.
+func dedent(line string, width int) string {
+	removed := 0
+	for i, r := range line {
+		if removed >= width {
+			return line[i:]
+		}
+		switch r {
+		case ' ':
+			removed++
+		case '\t':
+			tab := 4 - removed%4
+			if removed+tab > width {
+				return strings.Repeat(" ", removed+tab-width) + line[i+1:]
+			}
+			removed += tab
+		default:
+			return line[i:]
+		}
+	}
+	return ""
+}
+
+// blank returns whether the line holds only whitespace.
+func blank(line string) bool {
+	return strings.TrimSpace(line) == ""
+}
+
+// quoteMarker returns the rest of the line after the `>` that marks it as part of a block quote.
+func quoteMarker(line string) (string, bool) {
+	if indentation(line) > 3 {
+		return "", false
+	}
+	rest := strings.TrimLeft(line, " \t")
+	if !strings.HasPrefix(rest, ">") {
+		return "", false
+	}
+	rest = rest[1:]
+	if strings.HasPrefix(rest, " ") {
+		rest = rest[1:]
+	}
+	return rest, true
+}
+
+// listMarker reads the marker at the start of a list item, returning the marker itself (`-`, `*`, `+`, `.` or `)`),
+// the number an ordered item starts at, and the width of the marker and its indentation, which the lines of the item
+// after the first are indented by.
+func listMarker(line string) (string, int, int, bool) {
+	indent := indentation(line)
+	if indent > 3 {
+		return "", 0, 0, false
+	}
+	rest := strings.TrimLeft(line, " \t")
+	marker, number := "", 0
+	switch {
+	case rest == "":
+		return "", 0, 0, false
+	case strings.ContainsRune("-*+", rune(rest[0])):
+		marker = rest[:1]
+		rest = rest[1:]
+	default:
+		digits := len(rest) - len(strings.TrimLeft(rest, "0123456789"))
+		if digits == 0 || digits > 9 || digits == len(rest) || (rest[digits] != '.' && rest[digits] != ')') {
+			return "", 0, 0, false
+		}
+		number, _ = strconv.Atoi(rest[:digits])
+		marker = rest[digits : digits+1]
+		rest = rest[digits+1:]
+		indent += digits
+	}
+	if rest != "" && rest[0] != ' ' && rest[0] != '\t' {
+		return "", 0, 0, false
+	}
+	spaces := indentation(rest)
+	if spaces == 0 || spaces > 4 || blank(rest) {
+		spaces = 1
+	}
+	return marker, number, indent + 1 + spaces, true
+}
+
+// interrupts returns whether the line starts a block which ends a paragraph.
+func interrupts(line string) bool {
+	if fence, _ := md.OpenFence(line); fence != "" {
+		return true
+	}
+	if _, ok := quoteMarker(line); ok {
+		return true
+	}
+	if md.ThematicBreak(line) {
+		return true
+	}
+	_, number, _, ok := listMarker(line)
+	return ok && !blank(afterMarker(line)) && (number == 0 || number == 1)
+}
+
+// listItem returns whether the line starts a list item.
+func listItem(line string) bool {
+	_, _, _, ok := listMarker(line)
+	return ok
+}
+
+// afterMarker returns the text of the first line of a list item, after its marker.
+func afterMarker(line string) string {
+	rest := strings.TrimLeft(strings.TrimLeft(line, " \t"), "0123456789")
+	return strings.TrimLeft(rest[1:], " \t")
+}
+
+// parseBlocks reads the blocks of a card's body.
+func parseBlocks(text string) []*block {
+	lines := strings.Split(strings.Replace(text, "\r\n", "\n", -1), "\n")
+	blocks := []*block{}
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		if blank(line) {
+			i++
+			continue
+		}
+
+		// Fenced code runs until the closing fence or the end of the body.
+		if fence, language := md.OpenFence(line); fence != "" {
+			indent := indentation(line)
+			code := []string{}
+			for i++; i < len(lines) && !md.ClosesFence(fence, lines[i]); i++ {
+				code = append(code, dedent(lines[i], indent))
+			}
+			i++
+			blocks = append(blocks, &block{kind: codeBlock, text: strings.Join(code, "\n"), language: language})
+			continue
+		}
+
+		// Indented code runs until a line that is indented less, not counting blank lines.
+		if indentation(line) > 3 {
+			code := []string{}
+			for ; i < len(lines) && (blank(lines[i]) || indentation(lines[i]) > 3); i++ {
+				code = append(code, dedent(lines[i], 4))
+			}
+			for len(code) > 0 && blank(code[len(code)-1]) {
+				code = code[:len(code)-1]
+			}
+			blocks = append(blocks, &block{kind: codeBlock, text: strings.Join(code, "\n")})
+			continue
+		}
+
+		if md.ThematicBreak(line) {
+			blocks = append(blocks, &block{kind: ruleBlock})
+			i++
+			continue
+		}
+
+		// Quotes run until a blank line, taking in lines without a marker that carry on a paragraph.
+		if _, ok := quoteMarker(line); ok {
+			quoted := []string{}
+			for ; i < len(lines) && !blank(lines[i]); i++ {
+				rest, ok := quoteMarker(lines[i])
+				if !ok {
+					if interrupts(lines[i]) {
+						break
+					}
+					rest = lines[i]
+				}
+				quoted = append(quoted, rest)
+			}
+			blocks = append(blocks, &block{kind: quoteBlock, children: parseBlocks(strings.Join(quoted, "\n"))})
+			continue
+		}
+
+		if marker, _, _, ok := listMarker(line); ok {
+			var list *block
+			list, i = parseList(lines, i, marker)
+			blocks = append(blocks, list)
+			continue
+		}
+
+		// Anything else is a paragraph, which runs until a blank line or the start of another block.
+		paragraph := []string{strings.TrimLeft(line, " \t")}
+		for i++; i < len(lines) && !blank(lines[i]) && !interrupts(lines[i]); i++ {
+			paragraph = append(paragraph, strings.TrimLeft(lines[i], " \t"))
+		}
+		blocks = append(blocks, &block{kind: paragraphBlock, text: strings.TrimRight(strings.Join(paragraph, "\n"), " \t")})
+	}
+	return blocks
+}
+
+// parseList reads a list starting at the given line, made up of items with the same kind of marker, returning it and
+// the line after it.
+func parseList(lines []string, i int, marker string) (*block, int) {
+	list := &block{kind: listBlock, ordered: marker == "." || marker == ")", tight: true}
+	_, list.start, _, _ = listMarker(lines[i])
+	for i < len(lines) {
+		m, _, width, ok := listMarker(lines[i])
+		if !ok || m != marker {
+			break
+		}
+
+		// An item holds its first line, less the marker, and those after it that are indented past the marker, along
+		// with lines carrying on its last paragraph.
+		item := []string{afterMarker(lines[i])}
+		paragraph := !blank(item[0])
+		for i++; i < len(lines); i++ {
+			switch {
+			case blank(lines[i]):
+				item = append(item, "")
+				paragraph = false
+				continue
+			case indentation(lines[i]) >= width:
+				item = append(item, dedent(lines[i], width))
+				paragraph = true
+				continue
+			case listItem(lines[i]):
+				// Another item less indented than this one's contents ends it, even where it couldn't end a paragraph.
+			case paragraph && !interrupts(lines[i]):
+				item = append(item, lines[i])
+				continue
+			}
+			break
+		}
+
+		// Blank lines between items, or between the blocks of an item, make the list loose.
+		trailing := 0
+		for len(item) > 0 && blank(item[len(item)-1]) {
+			item = item[:len(item)-1]
+			trailing++
+		}
+		children := parseBlocks(strings.Join(item, "\n"))
+		if trailing > 0 && i < len(lines) {
+			if m, _, _, ok := listMarker(lines[i]); ok && m == marker {
+				list.tight = false
+			}
+		}
+		for j := 0; j < len(item); j++ {
+			if blank(item[j]) && j > 0 && len(children) > 1 {
+				list.tight = false
+			}
+		}
+		list.children = append(list.children, &block{kind: itemBlock, children: children})
+	}
+	return list, i
+}
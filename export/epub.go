@@ -0,0 +1,318 @@
+package export
+
+import (
+	"archive/zip"
+	"crypto/sha1"
+	"fmt"
+	"hash/crc32"
+	"html"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/makyo/mandelnote/notebook"
+)
+
+// epubContainer points reading systems at the package document.
+const epubContainer = `<?xml version="1.0" encoding="utf-8"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+<rootfiles>
+<rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/>
+</rootfiles>
+</container>
+`
+
+// epubStyle is the style sheet shared by the documents in an EPUB.
+const epubStyle = `body { font-family: serif; line-height: 1.4; }
+h1, h2, h3, h4, h5, h6 { font-family: sans-serif; }
+.title { text-align: center; margin-top: 30%; }
+.author { text-align: center; font-style: italic; }
+pre { white-space: pre-wrap; }
+blockquote { margin-left: 1em; font-style: italic; }
+`
+
+// epubImageTypes are the media types of the images which may be used as covers.
+var epubImageTypes = map[string]string{
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".png":  "image/png",
+	".gif":  "image/gif",
+	".svg":  "image/svg+xml",
+}
+
+// epubChapter is one of the XHTML documents making up an EPUB.
+type epubChapter struct {
+	file  string
+	title string
+	body  strings.Builder
+}
+
+// epubFile is a file within an EPUB's zip container.
+type epubFile struct {
+	name     string
+	contents []byte
+}
+
+// epub holds what is needed to split a notebook into the chapters of an EPUB.
+type epub struct {
+	opts     Options
+	language string
+	cover    string
+	depth    int
+
+	chapters []*epubChapter
+	current  *epubChapter
+	base     int
+	files    map[string]string
+}
+
+// newEPUB reads the settings for an EPUB, which are:
+//
+//	chapter    the depth of the cards which start new chapters, by default the shallowest exported; cards above it
+//	           are given chapters of their own
+//	cover      the path to an image to use as the cover, which may also be given as `cover` in the notebook's front
+//	           matter, relative to the notebook's file
+//	language   the language the book is written in, by default en
+func newEPUB(nb *notebook.Notebook, opts Options) (*epub, error) {
+	e := &epub{
+		opts:     opts,
+		language: "en",
+		depth:    opts.minDepth(),
+		files:    map[string]string{},
+	}
+	if cover, ok := nb.Meta("cover"); ok {
+		path, ok := cover.(string)
+		if !ok || path == "" {
+			return nil, fmt.Errorf("cover in the front matter must be a path")
+		}
+		if !filepath.IsAbs(path) && nb.File() != "" {
+			path = filepath.Join(filepath.Dir(nb.File()), path)
+		}
+		e.cover = path
+	}
+	for key, value := range opts.Settings {
+		switch key {
+		case "chapter":
+			depth, err := strconv.Atoi(value)
+			if err != nil || depth < 1 {
+				return nil, fmt.Errorf("invalid chapter depth %s", value)
+			}
+			if depth > e.depth {
+				e.depth = depth
+			}
+		case "cover":
+			e.cover = value
+		case "language":
+			e.language = value
+		default:
+			return nil, fmt.Errorf("unknown setting %s", key)
+		}
+	}
+	if _, ok := epubImageTypes[strings.ToLower(filepath.Ext(e.cover))]; e.cover != "" && !ok {
+		return nil, fmt.Errorf("cover must be a JPEG, PNG, GIF or SVG image")
+	}
+	return e, nil
+}
+
+// section writes the opening of the section for a card, along with its title and body.
+func (e *epub) section(c notebook.Card) {
+	level := c.Depth - e.base + 1
+	if level > 6 {
+		level = 6
+	}
+	fmt.Fprintf(&e.current.body, "<section id=\"%s\">\n<h%d>%s</h%d>\n%s", anchorID(c.ID), level,
+		html.EscapeString(c.Title), level, htmlBody(c.Body))
+	e.files[c.ID] = e.current.file
+}
+
+// write adds the cards to the chapters, starting a new chapter for each card at or above the chapter depth.
+func (e *epub) write(tree []notebook.Card) {
+	for _, c := range tree {
+		descend := e.opts.MaxDepth == 0 || c.Depth < e.opts.MaxDepth
+		if !e.opts.includes(c.Depth) {
+			if descend {
+				e.write(c.Children)
+			}
+			continue
+		}
+		if c.Depth <= e.depth {
+			e.current = &epubChapter{file: fmt.Sprintf("chapter-%d.xhtml", len(e.chapters)+1), title: c.Title}
+			e.chapters = append(e.chapters, e.current)
+			e.base = c.Depth
+		}
+		e.section(c)
+
+		// Cards above the chapter depth end before their children start chapters of their own.
+		if c.Depth < e.depth {
+			e.current.body.WriteString("</section>\n")
+		}
+		if descend {
+			e.write(c.Children)
+		}
+		if c.Depth >= e.depth {
+			e.current.body.WriteString("</section>\n")
+		}
+	}
+}
+
+// nav writes the entries of the table of contents for the cards.
+func (e *epub) nav(tree []notebook.Card) string {
+	out := ""
+	for _, c := range tree {
+		children := ""
+		if e.opts.MaxDepth == 0 || c.Depth < e.opts.MaxDepth {
+			children = e.nav(c.Children)
+		}
+		file, ok := e.files[c.ID]
+		if !ok {
+			out += children
+			continue
+		}
+		out += fmt.Sprintf("<li><a href=\"%s#%s\">%s</a>", file, anchorID(c.ID), html.EscapeString(c.Title))
+		if children != "" {
+			out += fmt.Sprintf("\n<ol>\n%s</ol>\n", children)
+		}
+		out += "</li>\n"
+	}
+	return out
+}
+
+// xhtml wraps the body of a document in the XHTML needed for an EPUB.
+func (e *epub) xhtml(title, body string) string {
+	return fmt.Sprintf(`<?xml version="1.0" encoding="utf-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops" lang="%s" xml:lang="%s">
+<head>
+<meta charset="utf-8" />
+<title>%s</title>
+<link rel="stylesheet" type="text/css" href="style.css" />
+</head>
+<body>
+%s</body>
+</html>
+`, html.EscapeString(e.language), html.EscapeString(e.language), html.EscapeString(title), body)
+}
+
+// identifier returns a unique identifier for the book, which stays the same each time the notebook is exported.
+func identifier(nb *notebook.Notebook) string {
+	sum := sha1.Sum([]byte(nb.Title + "\n" + nb.Created.UTC().String()))
+	sum[6] = sum[6]&0x0f | 0x50
+	sum[8] = sum[8]&0x3f | 0x80
+	return fmt.Sprintf("urn:uuid:%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
+
+// opf writes the package document, which lists the book's metadata, the files within it and the order to read them in.
+func (e *epub) opf(nb *notebook.Notebook, coverType string) string {
+	metadata := fmt.Sprintf(`<dc:identifier id="book-id">%s</dc:identifier>
+<dc:title>%s</dc:title>
+<dc:language>%s</dc:language>
+<meta property="dcterms:modified">%s</meta>
+`, identifier(nb), html.EscapeString(nb.Title), html.EscapeString(e.language),
+		nb.Modified.UTC().Format("2006-01-02T15:04:05Z"))
+	if nb.Author != "" {
+		metadata += fmt.Sprintf("<dc:creator>%s</dc:creator>\n", html.EscapeString(nb.Author))
+	}
+	if nb.Description != "" {
+		metadata += fmt.Sprintf("<dc:description>%s</dc:description>\n", html.EscapeString(nb.Description))
+	}
+	manifest := `<item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>
+<item id="style" href="style.css" media-type="text/css"/>
+<item id="title" href="title.xhtml" media-type="application/xhtml+xml"/>
+`
+	if e.cover != "" {
+		metadata += "<meta name=\"cover\" content=\"cover-image\"/>\n"
+		manifest += fmt.Sprintf("<item id=\"cover-image\" href=\"cover%s\" media-type=\"%s\" properties=\"cover-image\"/>\n",
+			strings.ToLower(filepath.Ext(e.cover)), coverType)
+	}
+	spine := "<itemref idref=\"title\"/>\n"
+	for i, ch := range e.chapters {
+		manifest += fmt.Sprintf("<item id=\"chapter-%d\" href=\"%s\" media-type=\"application/xhtml+xml\"/>\n", i+1, ch.file)
+		spine += fmt.Sprintf("<itemref idref=\"chapter-%d\"/>\n", i+1)
+	}
+	return fmt.Sprintf(`<?xml version="1.0" encoding="utf-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="book-id" xml:lang="%s">
+<metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+%s</metadata>
+<manifest>
+%s</manifest>
+<spine>
+%s</spine>
+</package>
+`, html.EscapeString(e.language), metadata, manifest, spine)
+}
+
+// book writes the notebook as an EPUB 3 ebook: a title page followed by a chapter for each card at the chapter depth,
+// with a table of contents mirroring the tree of cards.
+func book(w io.Writer, nb *notebook.Notebook, opts Options) error {
+	e, err := newEPUB(nb, opts)
+	if err != nil {
+		return err
+	}
+	var cover []byte
+	if e.cover != "" {
+		if cover, err = ioutil.ReadFile(e.cover); err != nil {
+			return fmt.Errorf("unable to read cover: %v", err)
+		}
+	}
+	tree := nb.GetTree()
+	e.write(tree)
+
+	title := fmt.Sprintf("<h1 class=\"title\">%s</h1>\n", html.EscapeString(nb.Title))
+	if nb.Author != "" {
+		title += fmt.Sprintf("<p class=\"author\">%s</p>\n", html.EscapeString(nb.Author))
+	}
+	if nb.Description != "" {
+		title += htmlBody(nb.Description)
+	}
+	nav := fmt.Sprintf("<nav epub:type=\"toc\" id=\"toc\">\n<h1>Contents</h1>\n<ol>\n<li><a href=\"title.xhtml\">%s</a></li>\n%s</ol>\n</nav>\n",
+		html.EscapeString(nb.Title), e.nav(tree))
+
+	files := []epubFile{
+		{"META-INF/container.xml", []byte(epubContainer)},
+		{"OEBPS/content.opf", []byte(e.opf(nb, epubImageTypes[strings.ToLower(filepath.Ext(e.cover))]))},
+		{"OEBPS/style.css", []byte(epubStyle)},
+		{"OEBPS/nav.xhtml", []byte(e.xhtml(nb.Title, nav))},
+		{"OEBPS/title.xhtml", []byte(e.xhtml(nb.Title, title))},
+	}
+	for _, ch := range e.chapters {
+		files = append(files, epubFile{"OEBPS/" + ch.file, []byte(e.xhtml(ch.title, ch.body.String()))})
+	}
+	if e.cover != "" {
+		files = append(files, epubFile{"OEBPS/cover" + strings.ToLower(filepath.Ext(e.cover)), cover})
+	}
+
+	// The mimetype must come first, stored uncompressed with no extra fields or data descriptor, so that the file can
+	// be recognised as an EPUB from its first bytes. It is written raw and without a modification time, since giving
+	// it one would add an extra field.
+	z := zip.NewWriter(w)
+	mimetype := []byte("application/epub+zip")
+	f, err := z.CreateRaw(&zip.FileHeader{
+		Name:               "mimetype",
+		Method:             zip.Store,
+		CRC32:              crc32.ChecksumIEEE(mimetype),
+		CompressedSize64:   uint64(len(mimetype)),
+		UncompressedSize64: uint64(len(mimetype)),
+	})
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(mimetype); err != nil {
+		return err
+	}
+	for _, file := range files {
+		f, err := z.CreateHeader(&zip.FileHeader{Name: file.name, Method: zip.Deflate, Modified: nb.Modified})
+		if err != nil {
+			return err
+		}
+		if _, err = f.Write(file.contents); err != nil {
+			return err
+		}
+	}
+	return z.Close()
+}
+
+func init() {
+	Register("epub", ExporterFunc(book))
+}
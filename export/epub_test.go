@@ -0,0 +1,160 @@
+package export_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/makyo/mandelnote/export"
+)
+
+// readEPUB returns the files in an EPUB, in the order they were written.
+func readEPUB(out string) ([]string, map[string]string) {
+	So(out[30:58], ShouldEqual, "mimetypeapplication/epub+zip")
+	r, err := zip.NewReader(strings.NewReader(out), int64(len(out)))
+	So(err, ShouldBeNil)
+	names := []string{}
+	files := map[string]string{}
+	for _, f := range r.File {
+		rc, err := f.Open()
+		So(err, ShouldBeNil)
+		contents, err := ioutil.ReadAll(rc)
+		So(err, ShouldBeNil)
+		rc.Close()
+		names = append(names, f.Name)
+		files[f.Name] = string(contents)
+		if f.Name == "mimetype" {
+			So(f.Method, ShouldEqual, zip.Store)
+			So(f.Extra, ShouldBeEmpty)
+			So(f.Flags&0x8, ShouldEqual, 0)
+		}
+	}
+	return names, files
+}
+
+// wellFormed returns whether the document is well-formed XML.
+func wellFormed(doc string) error {
+	decoder := xml.NewDecoder(strings.NewReader(doc))
+	for {
+		if _, err := decoder.Token(); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+func TestEPUB(t *testing.T) {
+	Convey("When exporting to EPUB", t, func() {
+		nb := testNotebook()
+		nb.Description = "A test of <everything> & more"
+
+		Convey("The container holds a package, navigation and chapters", func() {
+			out, err := exportString("epub", nb, export.Options{})
+			So(err, ShouldBeNil)
+			names, files := readEPUB(out)
+			So(names[0], ShouldEqual, "mimetype")
+			So(files["mimetype"], ShouldEqual, "application/epub+zip")
+			So(files["META-INF/container.xml"], ShouldContainSubstring, `full-path="OEBPS/content.opf"`)
+			for name, contents := range files {
+				if strings.HasSuffix(name, ".xml") || strings.HasSuffix(name, ".opf") || strings.HasSuffix(name, ".xhtml") {
+					So(wellFormed(contents), ShouldBeNil)
+				}
+			}
+
+			opf := files["OEBPS/content.opf"]
+			So(opf, ShouldContainSubstring, `version="3.0"`)
+			So(opf, ShouldContainSubstring, "<dc:title>Export</dc:title>")
+			So(opf, ShouldContainSubstring, "<dc:creator>Ada</dc:creator>")
+			So(opf, ShouldContainSubstring, "<dc:description>A test of &lt;everything&gt; &amp; more</dc:description>")
+			So(opf, ShouldContainSubstring, `properties="nav"`)
+			So(opf, ShouldContainSubstring, "<itemref idref=\"title\"/>\n<itemref idref=\"chapter-1\"/>\n<itemref idref=\"chapter-2\"/>\n")
+			So(opf, ShouldNotContainSubstring, "chapter-3")
+
+			So(files["OEBPS/chapter-1.xhtml"], ShouldContainSubstring, "<h1>Act 1</h1>")
+			So(files["OEBPS/chapter-1.xhtml"], ShouldContainSubstring, "<h3>Scene 1</h3>\n<p>The first scene.</p>\n")
+			So(files["OEBPS/nav.xhtml"], ShouldContainSubstring, `<nav epub:type="toc" id="toc">`)
+			So(files["OEBPS/nav.xhtml"], ShouldContainSubstring, "<li><a href=\"chapter-1.xhtml#c3\">Scene 1</a></li>\n")
+		})
+
+		Convey("The same notebook is given the same identifier", func() {
+			out, err := exportString("epub", nb, export.Options{})
+			So(err, ShouldBeNil)
+			out2, err := exportString("epub", nb, export.Options{})
+			So(err, ShouldBeNil)
+			So(out2, ShouldEqual, out)
+		})
+
+		Convey("Chapters may start deeper in the notebook", func() {
+			out, err := exportString("epub", nb, export.Options{Settings: map[string]string{"chapter": "2"}})
+			So(err, ShouldBeNil)
+			_, files := readEPUB(out)
+			So(files["OEBPS/chapter-1.xhtml"], ShouldContainSubstring, "<h1>Act 1</h1>\n<p>The act.</p>\n</section>\n")
+			So(files["OEBPS/chapter-2.xhtml"], ShouldContainSubstring, "<h1>Chapter 1</h1>")
+			So(files["OEBPS/chapter-2.xhtml"], ShouldContainSubstring, "<h2>Scene 2</h2>")
+			So(files["OEBPS/chapter-3.xhtml"], ShouldContainSubstring, "<h1>Chapter 2</h1>")
+			So(files["OEBPS/chapter-4.xhtml"], ShouldContainSubstring, "<h1>Act 2</h1>")
+			So(files["OEBPS/nav.xhtml"], ShouldContainSubstring, "<li><a href=\"chapter-3.xhtml#c6\">Scene 3</a></li>\n")
+		})
+
+		Convey("A cover image may be given", func() {
+			dir, err := ioutil.TempDir("", "mandelnote-epub")
+			So(err, ShouldBeNil)
+			defer os.RemoveAll(dir)
+			cover := filepath.Join(dir, "Cover.PNG")
+			So(ioutil.WriteFile(cover, []byte("not really a png"), 0644), ShouldBeNil)
+
+			out, err := exportString("epub", nb, export.Options{Settings: map[string]string{"cover": cover}})
+			So(err, ShouldBeNil)
+			_, files := readEPUB(out)
+			So(files["OEBPS/cover.png"], ShouldEqual, "not really a png")
+			So(files["OEBPS/content.opf"], ShouldContainSubstring,
+				`<item id="cover-image" href="cover.png" media-type="image/png" properties="cover-image"/>`)
+
+			Convey("Or in the front matter, relative to the notebook", func() {
+				So(ioutil.WriteFile(filepath.Join(dir, "other.gif"), []byte("not really a gif"), 0644), ShouldBeNil)
+				nb.SetFile(filepath.Join(dir, "novel.md"))
+				So(nb.SetMeta("cover", "other.gif"), ShouldBeNil)
+				out, err := exportString("epub", nb, export.Options{})
+				So(err, ShouldBeNil)
+				_, files := readEPUB(out)
+				So(files["OEBPS/cover.gif"], ShouldEqual, "not really a gif")
+
+				out, err = exportString("epub", nb, export.Options{Settings: map[string]string{"cover": cover}})
+				So(err, ShouldBeNil)
+				_, files = readEPUB(out)
+				So(files["OEBPS/cover.png"], ShouldEqual, "not really a png")
+				So(files, ShouldNotContainKey, "OEBPS/cover.gif")
+
+				So(nb.SetMeta("cover", 3), ShouldBeNil)
+				_, err = exportString("epub", nb, export.Options{})
+				So(err.Error(), ShouldEqual, "cover in the front matter must be a path")
+			})
+		})
+
+		Convey("Bad settings are errors", func() {
+			for key, message := range map[string]string{
+				"chapter": "invalid chapter depth [",
+				"cover":   "cover must be a JPEG, PNG, GIF or SVG image",
+				"colour":  "unknown setting colour",
+			} {
+				var buf bytes.Buffer
+				exporter, err := export.Get("epub")
+				So(err, ShouldBeNil)
+				err = exporter.Export(&buf, nb, export.Options{Settings: map[string]string{key: "["}})
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldEqual, message)
+			}
+			_, err := exportString("epub", nb, export.Options{Settings: map[string]string{"cover": "/nowhere.png"}})
+			So(err.Error(), ShouldStartWith, "unable to read cover")
+		})
+	})
+}
@@ -0,0 +1,96 @@
+// Package export writes notebooks out in formats other than the one they are stored in, such as for sharing or
+// publishing a finished piece.
+package export
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/makyo/mandelnote/notebook"
+)
+
+// Options control what is exported.
+type Options struct {
+	// MinDepth and MaxDepth limit the export to cards at depths between them, inclusive, with top-level cards at depth
+	// 1. Cards above MinDepth are left out, but not their children. A MaxDepth of 0 means there is no limit.
+	MinDepth int
+	MaxDepth int
+
+	// Settings hold options particular to an exporter, such as how to number chapters.
+	Settings map[string]string
+}
+
+// Exporter writes a notebook in some format.
+type Exporter interface {
+	Export(w io.Writer, nb *notebook.Notebook, opts Options) error
+}
+
+// ExporterFunc allows an ordinary function to be used as an Exporter.
+type ExporterFunc func(w io.Writer, nb *notebook.Notebook, opts Options) error
+
+// Export calls fn.
+func (fn ExporterFunc) Export(w io.Writer, nb *notebook.Notebook, opts Options) error {
+	return fn(w, nb, opts)
+}
+
+var exporters = map[string]Exporter{}
+
+// Register makes an exporter available by name. Registering two exporters with the same name is a programming error
+// and panics.
+func Register(name string, exporter Exporter) {
+	if _, ok := exporters[name]; ok {
+		panic(fmt.Sprintf("exporter %s is already registered", name))
+	}
+	exporters[name] = exporter
+}
+
+// Get returns the exporter with the given name.
+func Get(name string) (Exporter, error) {
+	exporter, ok := exporters[name]
+	if !ok {
+		return nil, fmt.Errorf("no exporter named %s", name)
+	}
+	return exporter, nil
+}
+
+// Names returns the names of the registered exporters, in alphabetical order.
+func Names() []string {
+	names := []string{}
+	for name := range exporters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// minDepth returns the shallowest depth to export.
+func (opts Options) minDepth() int {
+	if opts.MinDepth < 1 {
+		return 1
+	}
+	return opts.MinDepth
+}
+
+// includes returns whether cards at the given depth are exported.
+func (opts Options) includes(depth int) bool {
+	return depth >= opts.minDepth() && (opts.MaxDepth == 0 || depth <= opts.MaxDepth)
+}
+
+// level returns how deep the card is within the export, such that cards at MinDepth are at level 1.
+func (opts Options) level(c notebook.Card) int {
+	return c.Depth - opts.minDepth() + 1
+}
+
+// anchorID returns an identifier for the card which may be used as an HTML or XML ID or a LaTeX label, made up only
+// of letters, numbers, dashes and underscores and starting with a letter.
+func anchorID(id string) string {
+	valid := id != "" && strings.IndexFunc(id, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_')
+	}) == -1
+	if valid && (id[0] < '0' || id[0] > '9') && id[0] != '-' {
+		return id
+	}
+	return fmt.Sprintf("card-%x", id)
+}
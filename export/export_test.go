@@ -0,0 +1,88 @@
+package export_test
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/makyo/mandelnote/export"
+	"github.com/makyo/mandelnote/notebook"
+)
+
+// testNotebook returns a small notebook with three levels of cards.
+func testNotebook() *notebook.Notebook {
+	nb := notebook.New("", "Export", "Ada", "A test")
+	nb.AddCard("Act 1", "The act.", false)
+	nb.AddCard("Chapter 1", "The chapter.", true)
+	nb.AddCard("Scene 1", "The first scene.", true)
+	nb.AddCard("Scene 2", "The second scene.", false)
+	nb.Exit()
+	nb.AddCard("Chapter 2", "Another chapter.", false)
+	nb.AddCard("Scene 3", "The third scene.", true)
+	nb.Exit()
+	nb.Exit()
+	nb.AddCard("Act 2", "The second act.", false)
+	return nb
+}
+
+// exportString runs the named exporter and returns what it wrote.
+func exportString(name string, nb *notebook.Notebook, opts export.Options) (string, error) {
+	exporter, err := export.Get(name)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	err = exporter.Export(&buf, nb, opts)
+	return buf.String(), err
+}
+
+func TestRegistry(t *testing.T) {
+	Convey("When registering exporters", t, func() {
+
+		Convey("They can be looked up by name", func() {
+			So(export.Names(), ShouldContain, "markdown")
+			_, err := export.Get("markdown")
+			So(err, ShouldBeNil)
+		})
+
+		Convey("Unknown names are errors", func() {
+			_, err := export.Get("nothing")
+			So(err.Error(), ShouldEqual, "no exporter named nothing")
+		})
+
+		Convey("Names may only be registered once", func() {
+			exporter := export.ExporterFunc(func(w io.Writer, nb *notebook.Notebook, opts export.Options) error {
+				return nil
+			})
+			So(func() { export.Register("markdown", exporter) }, ShouldPanicWith, "exporter markdown is already registered")
+		})
+	})
+}
+
+func TestMarkdown(t *testing.T) {
+	Convey("When exporting to Markdown", t, func() {
+		nb := testNotebook()
+
+		Convey("The whole notebook is written as it would be saved", func() {
+			out, err := exportString("markdown", nb, export.Options{})
+			So(err, ShouldBeNil)
+			So(out, ShouldEqual, nb.Marshal())
+		})
+
+		Convey("The cards may be limited by depth", func() {
+			out, err := exportString("markdown", nb, export.Options{MinDepth: 2, MaxDepth: 2})
+			So(err, ShouldBeNil)
+			nb2, err := notebook.Unmarshal(out)
+			So(err, ShouldBeNil)
+			So(nb2.Title, ShouldEqual, "Export")
+			titles := []string{}
+			for _, c := range nb2.GetTree() {
+				titles = append(titles, c.Title)
+				So(c.Children, ShouldBeEmpty)
+			}
+			So(titles, ShouldResemble, []string{"Chapter 1", "Chapter 2"})
+		})
+	})
+}
@@ -0,0 +1,218 @@
+package export
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"strings"
+
+	"github.com/makyo/mandelnote/notebook"
+)
+
+// htmlStyle is the style sheet embedded in exported HTML, so that the file needs nothing else to be read.
+const htmlStyle = `
+body { margin: 0 auto; max-width: 50em; padding: 1em 2em; font-family: Georgia, serif; line-height: 1.5; color: #222; }
+header { border-bottom: 1px solid #ccc; margin-bottom: 1em; }
+.author { font-style: italic; }
+nav { background: #f6f6f6; border: 1px solid #ddd; padding: 0.5em 1em; margin-bottom: 2em; }
+nav h2 { margin: 0.25em 0; font-size: 1.1em; }
+nav ul { list-style: none; padding-left: 1.25em; margin: 0; }
+nav > ul { padding-left: 0; }
+nav li { margin: 0.15em 0; }
+nav summary { cursor: pointer; }
+nav a { color: #225; text-decoration: none; }
+nav a:hover { text-decoration: underline; }
+section { margin-left: 0; }
+section section { margin-left: 1em; padding-left: 1em; border-left: 2px solid #eee; }
+h2, h3, h4, h5, h6 { font-family: Helvetica, Arial, sans-serif; }
+pre { background: #f6f6f6; padding: 0.5em; overflow-x: auto; }
+code { font-family: Menlo, Consolas, monospace; font-size: 0.9em; }
+blockquote { margin-left: 0; padding-left: 1em; border-left: 3px solid #ccc; color: #555; }
+img { max-width: 100%; }
+`
+
+// urlScheme returns the scheme of the URL in lower case, such as `https`, or an empty string if the URL is relative.
+func urlScheme(url string) string {
+	url = strings.TrimSpace(url)
+	colon := strings.IndexByte(url, ':')
+	if colon == -1 || strings.ContainsAny(url[:colon], "/?#") {
+		return ""
+	}
+	return strings.ToLower(url[:colon])
+}
+
+// safeURL returns whether a link or image may point to the URL: only http, https and mailto URLs and relative ones may,
+// so that shared pages can't run scripts through links such as `javascript:`.
+func safeURL(url string) bool {
+	switch urlScheme(url) {
+	case "", "http", "https", "mailto":
+		return true
+	}
+	return false
+}
+
+// htmlInline renders spans of text as HTML. Links and images to URLs which aren't safe are left as their text.
+func htmlInline(spans []inline) string {
+	out := ""
+	for _, span := range spans {
+		switch span.kind {
+		case textInline:
+			out += html.EscapeString(span.text)
+		case codeInline:
+			out += "<code>" + html.EscapeString(span.text) + "</code>"
+		case emphasisInline:
+			out += "<em>" + htmlInline(span.children) + "</em>"
+		case strongInline:
+			out += "<strong>" + htmlInline(span.children) + "</strong>"
+		case linkInline:
+			if !safeURL(span.url) {
+				out += htmlInline(span.children)
+				continue
+			}
+			out += fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(span.url), htmlInline(span.children))
+		case imageInline:
+			if !safeURL(span.url) {
+				out += html.EscapeString(plainText(span.children))
+				continue
+			}
+			out += fmt.Sprintf(`<img src="%s" alt="%s" />`, html.EscapeString(span.url),
+				html.EscapeString(plainText(span.children)))
+		case breakInline:
+			out += "<br />\n"
+		}
+	}
+	return out
+}
+
+// htmlBlocks renders blocks as HTML. Paragraphs within the items of tight lists are written without <p>. The markup is
+// also valid XHTML.
+func htmlBlocks(blocks []*block, tight bool) string {
+	out := ""
+	for _, b := range blocks {
+		switch b.kind {
+		case paragraphBlock:
+			if tight {
+				out += htmlInline(parseInline(b.text)) + "\n"
+			} else {
+				out += "<p>" + htmlInline(parseInline(b.text)) + "</p>\n"
+			}
+		case codeBlock:
+			class := ""
+			if b.language != "" {
+				class = fmt.Sprintf(` class="language-%s"`, html.EscapeString(b.language))
+			}
+			out += fmt.Sprintf("<pre><code%s>%s\n</code></pre>\n", class, html.EscapeString(b.text))
+		case quoteBlock:
+			out += "<blockquote>\n" + htmlBlocks(b.children, false) + "</blockquote>\n"
+		case listBlock:
+			tag := "ul"
+			if b.ordered {
+				tag = "ol"
+			}
+			start := ""
+			if b.ordered && b.start != 1 {
+				start = fmt.Sprintf(` start="%d"`, b.start)
+			}
+			out += fmt.Sprintf("<%s%s>\n", tag, start)
+			for _, item := range b.children {
+				out += "<li>" + htmlBlocks(item.children, b.tight) + "</li>\n"
+			}
+			out += fmt.Sprintf("</%s>\n", tag)
+		case ruleBlock:
+			out += "<hr />\n"
+		}
+	}
+	return out
+}
+
+// htmlBody renders the body of a card as HTML.
+func htmlBody(body string) string {
+	return htmlBlocks(parseBlocks(body), false)
+}
+
+// htmlContents writes a table of contents for the cards to export, with a collapsible entry for each card with
+// children.
+func htmlContents(tree []notebook.Card, opts Options) string {
+	out := ""
+	for _, c := range tree {
+		children := ""
+		if opts.MaxDepth == 0 || c.Depth < opts.MaxDepth {
+			children = htmlContents(c.Children, opts)
+		}
+		if !opts.includes(c.Depth) {
+			out += children
+			continue
+		}
+		entry := fmt.Sprintf(`<a href="#%s">%s</a>`, anchorID(c.ID), html.EscapeString(c.Title))
+		if children == "" {
+			out += fmt.Sprintf("<li>%s</li>\n", entry)
+		} else {
+			out += fmt.Sprintf("<li><details open=\"open\"><summary>%s</summary>\n<ul>\n%s</ul></details></li>\n", entry,
+				children)
+		}
+	}
+	return out
+}
+
+// htmlCards writes a section for each card to export, holding its title, its body and the sections of its children.
+func htmlCards(tree []notebook.Card, opts Options) string {
+	out := ""
+	for _, c := range tree {
+		children := ""
+		if opts.MaxDepth == 0 || c.Depth < opts.MaxDepth {
+			children = htmlCards(c.Children, opts)
+		}
+		if !opts.includes(c.Depth) {
+			out += children
+			continue
+		}
+		level := opts.level(c) + 1
+		if level > 6 {
+			level = 6
+		}
+		out += fmt.Sprintf("<section id=\"%s\">\n<h%d>%s</h%d>\n%s%s</section>\n", anchorID(c.ID), level,
+			html.EscapeString(c.Title), level, htmlBody(c.Body), children)
+	}
+	return out
+}
+
+// standalone writes the notebook as a single HTML file with its style sheet embedded, a table of contents mirroring
+// the tree of cards, and a section for each card which may be linked to by the card's ID.
+func standalone(w io.Writer, nb *notebook.Notebook, opts Options) error {
+	tree := nb.GetTree()
+	header := fmt.Sprintf("<h1>%s</h1>\n", html.EscapeString(nb.Title))
+	if nb.Author != "" {
+		header += fmt.Sprintf("<p class=\"author\">%s</p>\n", html.EscapeString(nb.Author))
+	}
+	if nb.Description != "" {
+		header += fmt.Sprintf("<div class=\"description\">\n%s</div>\n", htmlBody(nb.Description))
+	}
+	_, err := fmt.Fprintf(w, `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8" />
+<meta name="viewport" content="width=device-width, initial-scale=1" />
+<meta name="author" content="%s" />
+<title>%s</title>
+<style>%s</style>
+</head>
+<body>
+<header>
+%s</header>
+<nav>
+<h2>Contents</h2>
+<ul>
+%s</ul>
+</nav>
+<main>
+%s</main>
+</body>
+</html>
+`, html.EscapeString(nb.Author), html.EscapeString(nb.Title), htmlStyle, header, htmlContents(tree, opts),
+		htmlCards(tree, opts))
+	return err
+}
+
+func init() {
+	Register("html", ExporterFunc(standalone))
+}
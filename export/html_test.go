@@ -0,0 +1,77 @@
+package export_test
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/makyo/mandelnote/export"
+	"github.com/makyo/mandelnote/notebook"
+)
+
+func TestHTML(t *testing.T) {
+	Convey("When exporting to HTML", t, func() {
+		nb := testNotebook()
+
+		Convey("The page stands alone", func() {
+			out, err := exportString("html", nb, export.Options{})
+			So(err, ShouldBeNil)
+			So(out, ShouldStartWith, "<!DOCTYPE html>\n")
+			So(out, ShouldContainSubstring, "<title>Export</title>")
+			So(out, ShouldContainSubstring, "<style>")
+			So(out, ShouldContainSubstring, `<p class="author">Ada</p>`)
+			So(out, ShouldNotContainSubstring, "http")
+		})
+
+		Convey("Each card has a section and an entry in the contents", func() {
+			out, err := exportString("html", nb, export.Options{})
+			So(err, ShouldBeNil)
+			So(out, ShouldContainSubstring, "<li><details open=\"open\"><summary><a href=\"#c1\">Act 1</a></summary>\n<ul>\n")
+			So(out, ShouldContainSubstring, "<li><a href=\"#c3\">Scene 1</a></li>\n")
+			So(out, ShouldContainSubstring, "<section id=\"c3\">\n<h4>Scene 1</h4>\n<p>The first scene.</p>\n</section>\n")
+		})
+
+		Convey("The cards may be limited by depth", func() {
+			out, err := exportString("html", nb, export.Options{MinDepth: 2, MaxDepth: 2})
+			So(err, ShouldBeNil)
+			So(out, ShouldContainSubstring, "<li><a href=\"#c2\">Chapter 1</a></li>\n<li><a href=\"#c5\">Chapter 2</a></li>\n")
+			So(out, ShouldContainSubstring, "<section id=\"c2\">\n<h2>Chapter 1</h2>\n<p>The chapter.</p>\n</section>\n")
+			So(out, ShouldNotContainSubstring, "Act 1")
+			So(out, ShouldNotContainSubstring, "Scene 1")
+		})
+
+		Convey("Bodies are rendered from Markdown", func() {
+			nb := notebook.New("", "Markdown", "", "")
+			for body, expected := range map[string]string{
+				"Some *emphasis*, __strong__ and `<code>`.": "<p>Some <em>emphasis</em>, <strong>strong</strong> and " +
+					"<code>&lt;code&gt;</code>.</p>\n",
+				"***both*** and snake_case_words":            "<p><strong><em>both</em></strong> and snake_case_words</p>\n",
+				"A [link](https://example.com \"t\")":        "<p>A <a href=\"https://example.com\">link</a></p>\n",
+				"![A *cat*](cat.png)":                        "<p><img src=\"cat.png\" alt=\"A cat\" /></p>\n",
+				"[x](javascript:alert(1)) [y](JavaScript:x)": "<p>x y</p>\n",
+				"[x](vbscript:x) [y](data:text/html,z)":      "<p>x y</p>\n",
+				"![A cat](javascript:x)":                     "<p>A cat</p>\n",
+				"[x](../notes.html?a=b:c) [y](#c:1)":         "<p><a href=\"../notes.html?a=b:c\">x</a> <a href=\"#c:1\">y</a></p>\n",
+				"<someone@example.com>":                      "<p><a href=\"mailto:someone@example.com\">someone@example.com</a></p>\n",
+				"Line one  \nline two\\\nthree":              "<p>Line one<br />\nline two<br />\nthree</p>\n",
+				"\\*not emphasis\\*":                         "<p>*not emphasis*</p>\n",
+				"> quoted\ncontinued\n\nafter":               "<blockquote>\n<p>quoted\ncontinued</p>\n</blockquote>\n<p>after</p>\n",
+				"- one\n- two\n  - nested":                   "<ul>\n<li>one\n</li>\n<li>two\n<ul>\n<li>nested\n</li>\n</ul>\n</li>\n</ul>\n",
+				"3. three\n4. four":                          "<ol start=\"3\">\n<li>three\n</li>\n<li>four\n</li>\n</ol>\n",
+				"3. three\n\n4. four":                        "<ol start=\"3\">\n<li><p>three</p>\n</li>\n<li><p>four</p>\n</li>\n</ol>\n",
+				"```go\nif a < b {\n```":                     "<pre><code class=\"language-go\">if a &lt; b {\n</code></pre>\n",
+				"    indented\n\n    code\nafter":            "<pre><code>indented\n\ncode\n</code></pre>\n<p>after</p>\n",
+				"above\n* * *\nbelow":                        "<p>above</p>\n<hr />\n<p>below</p>\n",
+			} {
+				nb.AddCard("Card", body, false)
+				c, err := nb.Lookup(nb.CurrentID())
+				So(err, ShouldBeNil)
+				out, err := exportString("html", nb, export.Options{})
+				So(err, ShouldBeNil)
+				So(out, ShouldContainSubstring, "<h2>Card</h2>\n"+expected+"</section>")
+				So(nb.Delete(true), ShouldBeNil)
+				So(c.Body, ShouldEqual, body)
+			}
+		})
+	})
+}
@@ -0,0 +1,271 @@
+package export
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// inlineKind is the kind of a span of text within a paragraph.
+type inlineKind int
+
+const (
+	textInline inlineKind = iota
+	codeInline
+	emphasisInline
+	strongInline
+	linkInline
+	imageInline
+	breakInline
+)
+
+// inline is a span of text within a paragraph. Text and code hold their text, links and images their destination, and
+// emphasis, links and images the spans within them.
+type inline struct {
+	kind     inlineKind
+	text     string
+	url      string
+	children []inline
+}
+
+// plainText returns the text of the spans without any markup, such as for the description of an image.
+func plainText(spans []inline) string {
+	text := ""
+	for _, span := range spans {
+		switch span.kind {
+		case textInline, codeInline:
+			text += span.text
+		case breakInline:
+			text += " "
+		default:
+			text += plainText(span.children)
+		}
+	}
+	return text
+}
+
+// isPunctuation returns whether the byte is ASCII punctuation, which may be escaped with a backslash.
+func isPunctuation(c byte) bool {
+	return c < utf8.RuneSelf && unicode.IsPunct(rune(c)) || strings.ContainsRune("$+<=>^`|~", rune(c))
+}
+
+// codeSpan reads the code span starting with the run of backticks at i, returning its text and its end, or -1 if it
+// isn't closed.
+func codeSpan(s string, i int) (string, int) {
+	ticks := len(s[i:]) - len(strings.TrimLeft(s[i:], "`"))
+	for j := i + ticks; j < len(s); {
+		if s[j] != '`' {
+			j++
+			continue
+		}
+		run := len(s[j:]) - len(strings.TrimLeft(s[j:], "`"))
+		if run == ticks {
+			code := strings.Replace(s[i+ticks:j], "\n", " ", -1)
+			if len(code) > 2 && code[0] == ' ' && code[len(code)-1] == ' ' && strings.TrimSpace(code) != "" {
+				code = code[1 : len(code)-1]
+			}
+			return code, j + run
+		}
+		j += run
+	}
+	return "", -1
+}
+
+// closer finds the run of delim which closes emphasis opened before from, and which is accepted by want, returning its
+// position and length, or -1.
+func closer(s string, from int, delim byte, want func(int) bool) (int, int) {
+	for j := from; j < len(s); {
+		switch s[j] {
+		case '\\':
+			j += 2
+			continue
+		case '`':
+			if _, end := codeSpan(s, j); end != -1 {
+				j = end
+				continue
+			}
+		case delim:
+			run := len(s[j:]) - len(strings.TrimLeft(s[j:], string(delim)))
+			before, _ := utf8.DecodeLastRuneInString(s[:j])
+			after, _ := utf8.DecodeRuneInString(s[j+run:])
+			if j > from && !unicode.IsSpace(before) && want(run) &&
+				(delim != '_' || !(unicode.IsLetter(after) || unicode.IsDigit(after))) {
+				return j, run
+			}
+			j += run
+			continue
+		}
+		j++
+	}
+	return -1, 0
+}
+
+// bracketed returns the end of the text in brackets starting at i, allowing for nested brackets, or -1.
+func bracketed(s string, i int, open, close byte) int {
+	depth := 0
+	for j := i; j < len(s); j++ {
+		switch s[j] {
+		case '\\':
+			j++
+		case open:
+			depth++
+		case close:
+			depth--
+			if depth == 0 {
+				return j
+			}
+		}
+	}
+	return -1
+}
+
+// link reads a link or image starting with the `[` at i, returning its text, its destination and its end, or -1.
+func link(s string, i int) (string, string, int) {
+	end := bracketed(s, i, '[', ']')
+	if end == -1 || end+1 >= len(s) || s[end+1] != '(' {
+		return "", "", -1
+	}
+	close := bracketed(s, end+1, '(', ')')
+	if close == -1 {
+		return "", "", -1
+	}
+	destination := strings.TrimSpace(s[end+2 : close])
+	if strings.HasPrefix(destination, "<") {
+		if gt := strings.Index(destination, ">"); gt != -1 {
+			destination = destination[1:gt]
+		}
+	} else if fields := strings.Fields(destination); len(fields) > 0 {
+		destination = fields[0]
+	}
+	return s[i+1 : end], unescape(destination), close + 1
+}
+
+// autolink reads a link written as `<https://example.com>` or `<someone@example.com>` starting at i, returning its
+// destination and end, or -1.
+func autolink(s string, i int) (string, int) {
+	end := strings.IndexByte(s[i:], '>')
+	if end == -1 {
+		return "", -1
+	}
+	text := s[i+1 : i+end]
+	if text == "" || strings.ContainsAny(text, " \t\n<") {
+		return "", -1
+	}
+	if colon := strings.Index(text, ":"); colon > 1 && !strings.ContainsAny(text[:colon], "/@.") {
+		return text, i + end + 1
+	}
+	if at := strings.Index(text, "@"); at > 0 && strings.Contains(text[at:], ".") {
+		return text, i + end + 1
+	}
+	return "", -1
+}
+
+// unescape removes backslashes from before punctuation.
+func unescape(s string) string {
+	result := strings.Builder{}
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) && isPunctuation(s[i+1]) {
+			i++
+		}
+		result.WriteByte(s[i])
+	}
+	return result.String()
+}
+
+// parseInline reads the spans of a paragraph: code, emphasis, links, images and line breaks.
+func parseInline(s string) []inline {
+	spans := []inline{}
+	text := strings.Builder{}
+	add := func(span inline) {
+		if text.Len() > 0 {
+			spans = append(spans, inline{kind: textInline, text: text.String()})
+			text.Reset()
+		}
+		spans = append(spans, span)
+	}
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s) && s[i+1] == '\n':
+			add(inline{kind: breakInline})
+			i += 2
+			continue
+		case c == '\\' && i+1 < len(s) && isPunctuation(s[i+1]):
+			text.WriteByte(s[i+1])
+			i += 2
+			continue
+		case c == '\n':
+			if strings.HasSuffix(text.String(), "  ") {
+				trimmed := strings.TrimRight(text.String(), " ")
+				text.Reset()
+				text.WriteString(trimmed)
+				add(inline{kind: breakInline})
+			} else {
+				text.WriteByte('\n')
+			}
+			i++
+			continue
+		case c == '`':
+			if code, end := codeSpan(s, i); end != -1 {
+				add(inline{kind: codeInline, text: code})
+				i = end
+				continue
+			}
+			run := len(s[i:]) - len(strings.TrimLeft(s[i:], "`"))
+			text.WriteString(s[i : i+run])
+			i += run
+			continue
+		case c == '!' && i+1 < len(s) && s[i+1] == '[':
+			if label, destination, end := link(s, i+1); end != -1 {
+				add(inline{kind: imageInline, url: destination, children: parseInline(label)})
+				i = end
+				continue
+			}
+		case c == '[':
+			if label, destination, end := link(s, i); end != -1 {
+				add(inline{kind: linkInline, url: destination, children: parseInline(label)})
+				i = end
+				continue
+			}
+		case c == '<':
+			if destination, end := autolink(s, i); end != -1 {
+				url := destination
+				if !strings.Contains(url, ":") {
+					url = "mailto:" + url
+				}
+				add(inline{kind: linkInline, url: url, children: []inline{{kind: textInline, text: destination}}})
+				i = end
+				continue
+			}
+		case c == '*' || c == '_':
+			run := len(s[i:]) - len(strings.TrimLeft(s[i:], string(c)))
+			next, _ := utf8.DecodeRuneInString(s[i+run:])
+			before, _ := utf8.DecodeLastRuneInString(s[:i])
+			opens := i+run < len(s) && !unicode.IsSpace(next) &&
+				(c != '_' || i == 0 || !(unicode.IsLetter(before) || unicode.IsDigit(before)))
+			if opens && run >= 2 {
+				if j, k := closer(s, i+run, c, func(k int) bool { return k >= 2 }); j != -1 {
+					add(inline{kind: strongInline, children: parseInline(s[i+2 : j+k-2])})
+					i = j + k
+					continue
+				}
+			}
+			if opens && run%2 == 1 {
+				if j, k := closer(s, i+run, c, func(k int) bool { return k%2 == 1 }); j != -1 {
+					add(inline{kind: emphasisInline, children: parseInline(s[i+1 : j+k-1])})
+					i = j + k
+					continue
+				}
+			}
+			text.WriteString(s[i : i+run])
+			i += run
+			continue
+		}
+		text.WriteByte(c)
+		i++
+	}
+	if text.Len() > 0 {
+		spans = append(spans, inline{kind: textInline, text: text.String()})
+	}
+	return spans
+}
@@ -0,0 +1,270 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/makyo/mandelnote/notebook"
+)
+
+// latexSections are the sectioning commands which cards may be mapped to, from the shallowest to the deepest.
+var latexSections = []string{"part", "chapter", "section", "subsection", "subsubsection", "paragraph", "subparagraph"}
+
+// latexEscapes replaces the characters which mean something to LaTeX.
+var latexEscapes = strings.NewReplacer(
+	`\`, `\textbackslash{}`,
+	"{", `\{`,
+	"}", `\}`,
+	"$", `\$`,
+	"&", `\&`,
+	"%", `\%`,
+	"#", `\#`,
+	"_", `\_`,
+	"~", `\textasciitilde{}`,
+	"^", `\textasciicircum{}`,
+)
+
+// latexURLEscapes replaces the characters which may not appear as they are in a URL given to \href or \url.
+var latexURLEscapes = strings.NewReplacer(
+	`\`, `\\`,
+	"{", `\{`,
+	"}", `\}`,
+	"%", `\%`,
+	"#", `\#`,
+)
+
+// latexAbstractClasses are the document classes known to have an abstract environment, and latexChapterClasses those
+// known to have chapters.
+var (
+	latexAbstractClasses = map[string]bool{"article": true, "report": true, "scrartcl": true, "scrreprt": true}
+	latexChapterClasses  = map[string]bool{"book": true, "report": true, "memoir": true, "scrbook": true, "scrreprt": true}
+)
+
+// latex holds what is needed to write a notebook as a LaTeX document.
+type latex struct {
+	opts     Options
+	class    string
+	sections []string
+	abstract bool
+}
+
+// newLaTeX reads the settings for a LaTeX document, which are:
+//
+//	class      the document class, by default article
+//	sections   the sectioning commands to use for each level of cards, separated by commas, such as
+//	           `chapter,section,subsection`. A command may end with * to leave it unnumbered, and cards deeper than
+//	           the list goes use the last one. By default, classes with chapters, such as book and report, start
+//	           at part, while others start at section.
+//	abstract   yes to put the notebook's description in an abstract, or no to write it as it is, which classes
+//	           without an abstract environment need. By default, only article and report and their KOMA-Script
+//	           counterparts use one.
+func newLaTeX(opts Options) (*latex, error) {
+	l := &latex{
+		opts:  opts,
+		class: "article",
+	}
+	for key, value := range opts.Settings {
+		switch key {
+		case "class":
+			if value == "" || strings.ContainsAny(value, `\{}[]% `) {
+				return nil, fmt.Errorf("invalid document class %q", value)
+			}
+			l.class = value
+		case "abstract":
+			switch strings.ToLower(value) {
+			case "yes", "true":
+				l.abstract = true
+			case "no", "false":
+				l.abstract = false
+			default:
+				return nil, fmt.Errorf("abstract must be yes or no, not %q", value)
+			}
+		case "sections":
+			for _, section := range strings.Split(value, ",") {
+				section = strings.TrimSpace(section)
+				known := false
+				for _, name := range latexSections {
+					known = known || strings.TrimSuffix(section, "*") == name
+				}
+				if !known {
+					return nil, fmt.Errorf("unknown sectioning command %q; must be one of %s", section,
+						strings.Join(latexSections, ", "))
+				}
+				l.sections = append(l.sections, section)
+			}
+		default:
+			return nil, fmt.Errorf("unknown setting %s", key)
+		}
+	}
+	if _, ok := opts.Settings["abstract"]; !ok {
+		l.abstract = latexAbstractClasses[l.class]
+	}
+	if l.sections == nil {
+		l.sections = latexSections[2:]
+		if latexChapterClasses[l.class] {
+			l.sections = latexSections
+		}
+	}
+	return l, nil
+}
+
+// latexInline renders spans of text as LaTeX. Links to URLs which aren't safe are left as their text, as are images,
+// which are also linked to rather than included when they aren't local files.
+func latexInline(spans []inline) string {
+	out := ""
+	for _, span := range spans {
+		switch span.kind {
+		case textInline:
+			out += latexEscapes.Replace(span.text)
+		case codeInline:
+			out += `\texttt{` + latexEscapes.Replace(span.text) + "}"
+		case emphasisInline:
+			out += `\emph{` + latexInline(span.children) + "}"
+		case strongInline:
+			out += `\textbf{` + latexInline(span.children) + "}"
+		case linkInline:
+			if !safeURL(span.url) {
+				out += latexInline(span.children)
+				continue
+			}
+			out += fmt.Sprintf(`\href{%s}{%s}`, latexURLEscapes.Replace(span.url), latexInline(span.children))
+		case imageInline:
+			alt := latexEscapes.Replace(plainText(span.children))
+			switch {
+			case !safeURL(span.url):
+				out += alt
+			case urlScheme(span.url) != "":
+				out += fmt.Sprintf(`\href{%s}{%s}`, latexURLEscapes.Replace(span.url), alt)
+			default:
+				out += fmt.Sprintf(`\includegraphics[width=\linewidth]{%s}`, latexURLEscapes.Replace(span.url))
+			}
+		case breakInline:
+			out += "\\\\\n"
+		}
+	}
+	return out
+}
+
+// latexCode writes a code block. Code which would end a verbatim environment early is set line by line in a typewriter
+// font instead.
+func latexCode(code string) string {
+	if !strings.Contains(code, `\end{verbatim}`) {
+		return "\\begin{verbatim}\n" + code + "\n\\end{verbatim}\n\n"
+	}
+	out := "\\begin{flushleft}\\ttfamily\n"
+	for _, line := range strings.Split(code, "\n") {
+		line = latexEscapes.Replace(strings.Replace(line, "\t", "    ", -1))
+		out += `\mbox{}` + strings.Replace(line, " ", `\ `, -1) + "\\\\\n"
+	}
+	return out + "\\end{flushleft}\n\n"
+}
+
+// latexCounters are the counters of nested ordered lists, which are set for lists which don't start at 1.
+var latexCounters = []string{"enumi", "enumii", "enumiii", "enumiv"}
+
+// latexBlocks renders blocks as LaTeX. Paragraphs within the items of tight lists aren't separated by blank lines.
+// Lists are nested the given number of ordered lists deep.
+func latexBlocks(blocks []*block, tight bool, nesting int) string {
+	out := ""
+	for _, b := range blocks {
+		switch b.kind {
+		case paragraphBlock:
+			out += latexInline(parseInline(b.text)) + "\n"
+			if !tight {
+				out += "\n"
+			}
+		case codeBlock:
+			out += latexCode(b.text)
+		case quoteBlock:
+			out += "\\begin{quote}\n" + latexBlocks(b.children, false, nesting) + "\\end{quote}\n\n"
+		case listBlock:
+			env := "itemize"
+			if b.ordered {
+				env = "enumerate"
+			}
+			out += fmt.Sprintf("\\begin{%s}\n", env)
+			if b.ordered && b.start != 1 && nesting < len(latexCounters) {
+				out += fmt.Sprintf("\\setcounter{%s}{%d}\n", latexCounters[nesting], b.start-1)
+			}
+			inner := nesting
+			if b.ordered {
+				inner++
+			}
+			for _, item := range b.children {
+				// An item starting with [ would otherwise be read as the item's label.
+				text := strings.TrimRight(latexBlocks(item.children, b.tight, inner), "\n")
+				if strings.HasPrefix(text, "[") {
+					out += `\item{} ` + text + "\n"
+				} else {
+					out += `\item ` + text + "\n"
+				}
+			}
+			out += fmt.Sprintf("\\end{%s}\n\n", env)
+		case ruleBlock:
+			out += "\\begin{center}\\rule{0.5\\linewidth}{0.4pt}\\end{center}\n\n"
+		}
+	}
+	return out
+}
+
+// section returns the sectioning command for a card.
+func (l *latex) section(c notebook.Card) string {
+	level := l.opts.level(c)
+	if level > len(l.sections) {
+		level = len(l.sections)
+	}
+	return l.sections[level-1]
+}
+
+// cards writes the sections for the cards to export, each with its title and body.
+func (l *latex) cards(tree []notebook.Card) string {
+	out := ""
+	for _, c := range tree {
+		if l.opts.includes(c.Depth) {
+			out += fmt.Sprintf("\\%s{%s}\n\\label{card:%s}\n\n%s", l.section(c), latexEscapes.Replace(c.Title),
+				anchorID(c.ID), latexBlocks(parseBlocks(c.Body), false, 0))
+		}
+		if l.opts.MaxDepth == 0 || c.Depth < l.opts.MaxDepth {
+			out += l.cards(c.Children)
+		}
+	}
+	return out
+}
+
+// document writes the notebook as a LaTeX document, with its title and author in the title and its description as
+// the abstract, where there is to be one.
+func document(w io.Writer, nb *notebook.Notebook, opts Options) error {
+	l, err := newLaTeX(opts)
+	if err != nil {
+		return err
+	}
+	abstract := ""
+	if nb.Description != "" {
+		if !l.abstract {
+			abstract = latexBlocks(parseBlocks(nb.Description), false, 0)
+		} else {
+			abstract = "\\begin{abstract}\n" + latexBlocks(parseBlocks(nb.Description), false, 0) + "\\end{abstract}\n\n"
+		}
+	}
+	_, err = fmt.Fprintf(w, `\documentclass{%s}
+\usepackage[utf8]{inputenc}
+\usepackage[T1]{fontenc}
+\usepackage{graphicx}
+\usepackage{hyperref}
+
+\title{%s}
+\author{%s}
+
+\begin{document}
+
+\maketitle
+
+%s%s\end{document}
+`, l.class, latexEscapes.Replace(nb.Title), latexEscapes.Replace(nb.Author), abstract, l.cards(nb.GetTree()))
+	return err
+}
+
+func init() {
+	Register("latex", ExporterFunc(document))
+}
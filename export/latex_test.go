@@ -0,0 +1,99 @@
+package export_test
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/makyo/mandelnote/export"
+	"github.com/makyo/mandelnote/notebook"
+)
+
+func TestLaTeX(t *testing.T) {
+	Convey("When exporting to LaTeX", t, func() {
+		nb := testNotebook()
+		nb.Title = "Costs & Benefits of 100% #1"
+
+		Convey("The document has a title and abstract", func() {
+			out, err := exportString("latex", nb, export.Options{})
+			So(err, ShouldBeNil)
+			So(out, ShouldStartWith, "\\documentclass{article}\n")
+			So(out, ShouldContainSubstring, "\\title{Costs \\& Benefits of 100\\% \\#1}\n\\author{Ada}\n")
+			So(out, ShouldContainSubstring, "\\maketitle\n\n\\begin{abstract}\nA test\n\n\\end{abstract}\n")
+			So(out, ShouldEndWith, "\\end{document}\n")
+		})
+
+		Convey("Card depths are mapped to sections", func() {
+			out, err := exportString("latex", nb, export.Options{})
+			So(err, ShouldBeNil)
+			So(out, ShouldContainSubstring, "\\section{Act 1}\n\\label{card:c1}\n\nThe act.\n\n\\subsection{Chapter 1}\n")
+			So(out, ShouldContainSubstring, "\\subsubsection{Scene 1}\n")
+
+			out, err = exportString("latex", nb, export.Options{Settings: map[string]string{"class": "book"}})
+			So(err, ShouldBeNil)
+			So(out, ShouldContainSubstring, "\\part{Act 1}\n")
+			So(out, ShouldContainSubstring, "\\chapter{Chapter 1}\n")
+			So(out, ShouldNotContainSubstring, "abstract")
+
+			out, err = exportString("latex", nb, export.Options{Settings: map[string]string{"class": "scrbook"}})
+			So(err, ShouldBeNil)
+			So(out, ShouldContainSubstring, "\\maketitle\n\nA test\n\n\\part{Act 1}")
+			out, err = exportString("latex", nb, export.Options{
+				Settings: map[string]string{"class": "scrbook", "abstract": "yes"},
+			})
+			So(err, ShouldBeNil)
+			So(out, ShouldContainSubstring, "\\begin{abstract}")
+			out, err = exportString("latex", nb, export.Options{Settings: map[string]string{"abstract": "no"}})
+			So(err, ShouldBeNil)
+			So(out, ShouldNotContainSubstring, "abstract")
+
+			out, err = exportString("latex", nb, export.Options{
+				MinDepth: 2,
+				Settings: map[string]string{"sections": "chapter, section*"},
+			})
+			So(err, ShouldBeNil)
+			So(out, ShouldContainSubstring, "\\chapter{Chapter 1}\n")
+			So(out, ShouldContainSubstring, "\\section*{Scene 1}\n")
+			So(out, ShouldNotContainSubstring, "Act 1")
+		})
+
+		Convey("Bodies are converted from Markdown", func() {
+			nb := notebook.New("", "Markdown", "", "")
+			for body, expected := range map[string]string{
+				"Some *emphasis*, **strong** and `a_b`.":  "Some \\emph{emphasis}, \\textbf{strong} and \\texttt{a\\_b}.\n\n",
+				"50% of $5 & ~{x}^2\\":                    "50\\% of \\$5 \\& \\textasciitilde{}\\{x\\}\\textasciicircum{}2\\textbackslash{}\n\n",
+				"A [link](https://example.com/#top)":      "A \\href{https://example.com/\\#top}{link}\n\n",
+				"![Plot](plot.pdf)":                       "\\includegraphics[width=\\linewidth]{plot.pdf}\n\n",
+				"![A *plot*](https://example.com/p.png)":  "\\href{https://example.com/p.png}{A plot}\n\n",
+				"![Plot](javascript:x) [y](javascript:x)": "Plot y\n\n",
+				"> Quoted":                             "\\begin{quote}\nQuoted\n\n\\end{quote}\n\n",
+				"- one\n- two":                         "\\begin{itemize}\n\\item one\n\\item two\n\\end{itemize}\n\n",
+				"- [ ] task\n- [sic] *x*":              "\\begin{itemize}\n\\item{} [ ] task\n\\item{} [sic] \\emph{x}\n\\end{itemize}\n\n",
+				"3. three\n4. four\n   1. nested":      "\\begin{enumerate}\n\\setcounter{enumi}{2}\n\\item three\n\\item four\n\\begin{enumerate}\n\\item nested\n\\end{enumerate}\n\\end{enumerate}\n\n",
+				"```\n$x_1$ {}\n```":                   "\\begin{verbatim}\n$x_1$ {}\n\\end{verbatim}\n\n",
+				"```\n\\end{verbatim} a\n\n  [b]\n```": "\\begin{flushleft}\\ttfamily\n\\mbox{}\\textbackslash{}end\\{verbatim\\}\\ a\\\\\n\\mbox{}\\\\\n\\mbox{}\\ \\ [b]\\\\\n\\end{flushleft}\n\n",
+				"one  \ntwo":                           "one\\\\\ntwo\n\n",
+			} {
+				nb.AddCard("Card", body, false)
+				out, err := exportString("latex", nb, export.Options{})
+				So(err, ShouldBeNil)
+				So(out, ShouldContainSubstring, "\\section{Card}\n\\label{card:"+nb.CurrentID()+"}\n\n"+expected+"\\end{document}")
+				So(nb.Delete(true), ShouldBeNil)
+			}
+		})
+
+		Convey("Bad settings are errors", func() {
+			for key, message := range map[string]string{
+				"class": `invalid document class "{"`,
+				"sections": `unknown sectioning command "{"; must be one of part, chapter, section, subsection, ` +
+					"subsubsection, paragraph, subparagraph",
+				"abstract": `abstract must be yes or no, not "{"`,
+				"colour":   "unknown setting colour",
+			} {
+				_, err := exportString("latex", nb, export.Options{Settings: map[string]string{key: "{"}})
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldEqual, message)
+			}
+		})
+	})
+}
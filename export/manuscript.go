@@ -0,0 +1,152 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/makyo/mandelnote/notebook"
+)
+
+// DefaultSeparator is placed between scenes in a manuscript.
+const DefaultSeparator = "* * *"
+
+// manuscript holds what is needed to flatten a notebook into its prose.
+type manuscript struct {
+	opts      Options
+	separator string
+	exclude   *regexp.Regexp
+
+	// headings maps the depths which become headings to the format of those headings, and levels to the level of
+	// Markdown header used for them. numbers counts the headings written at each depth so far.
+	headings map[int]string
+	levels   map[int]int
+	numbers  map[int]int
+
+	parts []string
+}
+
+// newManuscript reads the settings for a manuscript, which are:
+//
+//	heading.N   turns cards at depth N into headings, such as `Chapter {n}`, where {n} is replaced with the number
+//	            of the heading, {I} and {i} with the number in upper or lower case Roman numerals, and {title} with
+//	            the card's title. Numbering carries on from one parent card to the next.
+//	separator   goes between neighbouring cards with no children, instead of `* * *`
+//	exclude     is a regular expression; cards with titles matching it are left out, along with their children
+func newManuscript(opts Options) (*manuscript, error) {
+	m := &manuscript{
+		opts:      opts,
+		separator: DefaultSeparator,
+		headings:  map[int]string{},
+		levels:    map[int]int{},
+		numbers:   map[int]int{},
+	}
+	for key, value := range opts.Settings {
+		switch {
+		case key == "separator":
+			m.separator = value
+		case key == "exclude":
+			exclude, err := regexp.Compile(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid exclude pattern: %v", err)
+			}
+			m.exclude = exclude
+		case strings.HasPrefix(key, "heading."):
+			depth, err := strconv.Atoi(strings.TrimPrefix(key, "heading."))
+			if err != nil || depth < 1 {
+				return nil, fmt.Errorf("invalid heading depth in %s", key)
+			}
+			m.headings[depth] = value
+		default:
+			return nil, fmt.Errorf("unknown setting %s", key)
+		}
+	}
+	depths := []int{}
+	for depth := range m.headings {
+		depths = append(depths, depth)
+	}
+	sort.Ints(depths)
+	for i, depth := range depths {
+		m.levels[depth] = i + 1
+	}
+	return m, nil
+}
+
+// roman returns the number in upper case Roman numerals.
+func roman(n int) string {
+	if n <= 0 {
+		return strconv.Itoa(n)
+	}
+	values := []int{1000, 900, 500, 400, 100, 90, 50, 40, 10, 9, 5, 4, 1}
+	symbols := []string{"M", "CM", "D", "CD", "C", "XC", "L", "XL", "X", "IX", "V", "IV", "I"}
+	result := ""
+	for i, value := range values {
+		for n >= value {
+			result += symbols[i]
+			n -= value
+		}
+	}
+	return result
+}
+
+// heading writes the heading for a card at one of the depths which become headings.
+func (m *manuscript) heading(c notebook.Card) {
+	m.numbers[c.Depth]++
+	n := m.numbers[c.Depth]
+	text := strings.NewReplacer(
+		"{n}", strconv.Itoa(n),
+		"{I}", roman(n),
+		"{i}", strings.ToLower(roman(n)),
+		"{title}", c.Title,
+	).Replace(m.headings[c.Depth])
+	m.parts = append(m.parts, fmt.Sprintf("%s %s", strings.Repeat("#", m.levels[c.Depth]), text))
+}
+
+// write adds the headings and bodies of the cards and their children, putting separators between neighbouring cards
+// which have no children of their own.
+func (m *manuscript) write(tree []notebook.Card) {
+	afterLeaf := false
+	for _, c := range tree {
+		if m.exclude != nil && m.exclude.MatchString(c.Title) {
+			continue
+		}
+		if _, ok := m.headings[c.Depth]; ok {
+			m.heading(c)
+			afterLeaf = false
+		}
+		leaf := len(c.Children) == 0 || c.Depth == m.opts.MaxDepth
+		if body := strings.Trim(c.Body, "\n"); body != "" && m.opts.includes(c.Depth) {
+			if leaf && afterLeaf {
+				m.parts = append(m.parts, m.separator)
+			}
+			m.parts = append(m.parts, body)
+			afterLeaf = leaf
+		}
+		if !leaf {
+			m.write(c.Children)
+			afterLeaf = false
+		}
+	}
+}
+
+// flattened writes the prose of the notebook without the outline around it: only the bodies of the cards within the
+// depths to export, with headings for the chosen depths and separators between scenes.
+func flattened(w io.Writer, nb *notebook.Notebook, opts Options) error {
+	m, err := newManuscript(opts)
+	if err != nil {
+		return err
+	}
+	m.write(nb.GetTree())
+	if len(m.parts) == 0 {
+		return nil
+	}
+	_, err = fmt.Fprintf(w, "%s\n", strings.Join(m.parts, "\n\n"))
+	return err
+}
+
+func init() {
+	Register("manuscript", ExporterFunc(flattened))
+}
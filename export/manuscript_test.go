@@ -0,0 +1,57 @@
+package export_test
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/makyo/mandelnote/export"
+)
+
+func TestManuscript(t *testing.T) {
+	Convey("When exporting a manuscript", t, func() {
+		nb := testNotebook()
+
+		Convey("Only the bodies of cards are written, with separators between scenes", func() {
+			out, err := exportString("manuscript", nb, export.Options{MinDepth: 3})
+			So(err, ShouldBeNil)
+			So(out, ShouldEqual, "The first scene.\n\n* * *\n\nThe second scene.\n\nThe third scene.\n")
+		})
+
+		Convey("Chosen depths become numbered headings", func() {
+			out, err := exportString("manuscript", nb, export.Options{
+				MinDepth: 3,
+				Settings: map[string]string{
+					"heading.1": "Part {I}",
+					"heading.2": "Chapter {n}: {title}",
+					"separator": "#",
+				},
+			})
+			So(err, ShouldBeNil)
+			So(out, ShouldEqual, "# Part I\n\n## Chapter 1: Chapter 1\n\nThe first scene.\n\n#\n\nThe second scene.\n\n"+
+				"## Chapter 2: Chapter 2\n\nThe third scene.\n\n# Part II\n")
+		})
+
+		Convey("Cards may be excluded by title", func() {
+			out, err := exportString("manuscript", nb, export.Options{
+				MinDepth: 2,
+				MaxDepth: 2,
+				Settings: map[string]string{"exclude": "^Chapter 1$"},
+			})
+			So(err, ShouldBeNil)
+			So(out, ShouldEqual, "Another chapter.\n")
+		})
+
+		Convey("Bad settings are errors", func() {
+			for key, message := range map[string]string{
+				"exclude":   "invalid exclude pattern: error parsing regexp: missing closing ]: `[`",
+				"heading.x": "invalid heading depth in heading.x",
+				"colour":    "unknown setting colour",
+			} {
+				_, err := exportString("manuscript", nb, export.Options{Settings: map[string]string{key: "["}})
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldEqual, message)
+			}
+		})
+	})
+}
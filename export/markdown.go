@@ -0,0 +1,23 @@
+package export
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/makyo/mandelnote/notebook"
+)
+
+// markdown writes the notebook in the same format it is saved in, so that an export of every card may be opened
+// again as a notebook.
+func markdown(w io.Writer, nb *notebook.Notebook, opts Options) error {
+	header, err := nb.MarshalHeader()
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "---\n%s\n---\n%s", header, nb.MarshalDepths(opts.minDepth(), opts.MaxDepth))
+	return err
+}
+
+func init() {
+	Register("markdown", ExporterFunc(markdown))
+}
@@ -0,0 +1,68 @@
+// Package markdown holds the pieces of reading Markdown which are shared between reading notebooks and exporting them,
+// so that both agree on where blocks such as fenced code start and end.
+package markdown
+
+import (
+	"strings"
+)
+
+// Indentation returns the width of the whitespace at the start of the line, with tabs stopping every four columns, and
+// the number of bytes it takes up.
+func Indentation(line string) (int, int) {
+	width := 0
+	for i, r := range line {
+		switch r {
+		case ' ':
+			width++
+		case '\t':
+			width += 4 - width%4
+		default:
+			return width, i
+		}
+	}
+	return width, len(line)
+}
+
+// Unindent strips up to three spaces from the start of the line, returning false if it is indented any further.
+func Unindent(line string) (string, bool) {
+	width, size := Indentation(line)
+	if width > 3 {
+		return line, false
+	}
+	return line[size:], true
+}
+
+// OpenFence returns the run of backticks or tildes that opens a fenced code block, along with the language given after
+// it, if the line opens one. Otherwise, the fence is empty.
+func OpenFence(line string) (string, string) {
+	rest, ok := Unindent(line)
+	if !ok || len(rest) < 3 || (rest[0] != '`' && rest[0] != '~') {
+		return "", ""
+	}
+	fence := rest[:len(rest)-len(strings.TrimLeft(rest, rest[:1]))]
+	info := strings.TrimSpace(rest[len(fence):])
+	if len(fence) < 3 || (fence[0] == '`' && strings.Contains(info, "`")) {
+		return "", ""
+	}
+	return fence, strings.SplitN(info, " ", 2)[0]
+}
+
+// ClosesFence returns whether the line closes the fenced code block opened with the given fence.
+func ClosesFence(fence, line string) bool {
+	rest, ok := Unindent(line)
+	if !ok {
+		return false
+	}
+	rest = strings.TrimRight(rest, " \t")
+	return len(rest) >= len(fence) && strings.Trim(rest, fence[:1]) == ""
+}
+
+// ThematicBreak returns whether the line is a horizontal rule such as `***` or `- - -`.
+func ThematicBreak(line string) bool {
+	rest, ok := Unindent(line)
+	if !ok {
+		return false
+	}
+	rest = strings.Replace(strings.Replace(rest, " ", "", -1), "\t", "", -1)
+	return len(rest) >= 3 && (strings.Trim(rest, "*") == "" || strings.Trim(rest, "-") == "" || strings.Trim(rest, "_") == "")
+}
@@ -0,0 +1,46 @@
+package markdown_test
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/makyo/mandelnote/internal/markdown"
+)
+
+func TestMarkdown(t *testing.T) {
+	Convey("When reading lines of Markdown", t, func() {
+
+		Convey("Indentation counts tabs to the next stop", func() {
+			width, size := markdown.Indentation(" \tx")
+			So(width, ShouldEqual, 4)
+			So(size, ShouldEqual, 2)
+			rest, ok := markdown.Unindent("   x")
+			So(rest, ShouldEqual, "x")
+			So(ok, ShouldBeTrue)
+			_, ok = markdown.Unindent("\tx")
+			So(ok, ShouldBeFalse)
+		})
+
+		Convey("Fences are opened with a language and closed by a fence at least as long", func() {
+			fence, language := markdown.OpenFence("  ````go run")
+			So(fence, ShouldEqual, "````")
+			So(language, ShouldEqual, "go")
+			fence, _ = markdown.OpenFence("``` a`b")
+			So(fence, ShouldEqual, "")
+			fence, _ = markdown.OpenFence("    ```")
+			So(fence, ShouldEqual, "")
+			So(markdown.ClosesFence("````", " ````` \t"), ShouldBeTrue)
+			So(markdown.ClosesFence("````", "```"), ShouldBeFalse)
+			So(markdown.ClosesFence("```", "~~~"), ShouldBeFalse)
+			So(markdown.ClosesFence("```", "``` x"), ShouldBeFalse)
+		})
+
+		Convey("Thematic breaks are runs of the same character", func() {
+			So(markdown.ThematicBreak(" - - -"), ShouldBeTrue)
+			So(markdown.ThematicBreak("***"), ShouldBeTrue)
+			So(markdown.ThematicBreak("*-*"), ShouldBeFalse)
+			So(markdown.ThematicBreak("    ---"), ShouldBeFalse)
+		})
+	})
+}
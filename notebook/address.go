@@ -0,0 +1,227 @@
+package notebook
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// newID returns a new identifier for a card, unique within the notebook.
+func (nb *Notebook) newID() string {
+	nb.lastID++
+	return fmt.Sprintf("c%d", nb.lastID)
+}
+
+// assignIDs makes sure that every card in the notebook has a unique ID, keeping existing ones where possible.
+func (nb *Notebook) assignIDs() {
+	seen := map[string]bool{}
+	bump := func(c *card) {
+		if !strings.HasPrefix(c.id, "c") {
+			return
+		}
+		if n, err := strconv.Atoi(c.id[1:]); err == nil && n > nb.lastID {
+			nb.lastID = n
+		}
+	}
+	nb.root.walk(bump)
+
+	// Cards in the trash keep their IDs so that they can be restored, so they count too.
+	for _, t := range nb.trash {
+		bump(t.card)
+		t.card.walk(bump)
+	}
+	assign := func(c *card) {
+		if c.id == "" || seen[c.id] {
+			c.id = nb.newID()
+		}
+		seen[c.id] = true
+	}
+	nb.root.walk(assign)
+	for _, t := range nb.trash {
+		assign(t.card)
+		t.card.walk(assign)
+	}
+}
+
+// walk calls fn for each descendant of the card, depth first and in order.
+func (c *card) walk(fn func(*card)) {
+	for curr := c.firstChild; curr != nil; curr = curr.next {
+		fn(curr)
+		curr.walk(fn)
+	}
+}
+
+// find returns the descendant of the card with the given ID, or nil if there is none.
+func (c *card) find(id string) *card {
+	var found *card
+	c.walk(func(curr *card) {
+		if found == nil && curr.id == id {
+			found = curr
+		}
+	})
+	return found
+}
+
+// attached returns whether or not the card is still part of the notebook's tree.
+func (nb *Notebook) attached(c *card) bool {
+	for curr := c; curr != nb.root; curr = curr.parent {
+		if curr == nil || curr.parent == nil {
+			return false
+		}
+		sibling := curr.parent.firstChild
+		for sibling != nil && sibling != curr {
+			sibling = sibling.next
+		}
+		if sibling == nil {
+			return false
+		}
+	}
+	return true
+}
+
+func (nb *Notebook) byID(id string) (*card, error) {
+	c := nb.root.find(id)
+	if c == nil {
+		return nil, fmt.Errorf("no card with ID %s", id)
+	}
+	return c, nil
+}
+
+func (nb *Notebook) byPath(path []int) (*card, error) {
+	if len(path) == 0 {
+		return nil, fmt.Errorf("path must not be empty")
+	}
+	c := nb.root.at(path)
+	if c == nil {
+		return nil, fmt.Errorf("no card at path %v", path)
+	}
+	return c, nil
+}
+
+// place returns the depth of the card, with the top level at 1, and its position among its siblings, starting at 1.
+// Cards which aren't in the tree are at depth 0 and position 0.
+func (c *card) place() (int, int) {
+	path := c.path()
+	if len(path) == 0 {
+		return 0, 0
+	}
+	return len(path), path[len(path)-1] + 1
+}
+
+// snapshot returns the public representation of the card and its children, given the card's depth and position.
+func (c *card) snapshot(nb *Notebook, depth, position int) Card {
+	result := Card{
+		ID:         c.id,
+		Title:      c.title,
+		Body:       c.body,
+		Current:    c == nb.currentCard,
+		Depth:      depth,
+		Position:   position,
+		Children:   c.getTree(nb, depth),
+		Counts:     count(c.body),
+		Target:     c.target,
+		Status:     c.status,
+		Tags:       append([]string{}, c.tags...),
+		Properties: append(Properties{}, c.properties...),
+	}
+	result.Total = result.Counts
+	for _, child := range result.Children {
+		result.Total = result.Total.Add(child.Total)
+		result.TotalTarget += child.TotalTarget
+	}
+	if c.target > 0 {
+		result.TotalTarget = c.target
+	}
+	return result
+}
+
+// CurrentID returns the ID of the current card, or an empty string if there are no cards.
+func (nb *Notebook) CurrentID() string {
+	return nb.currentCard.id
+}
+
+// CurrentPath returns the index path to the current card.
+func (nb *Notebook) CurrentPath() []int {
+	return nb.currentCard.path()
+}
+
+// Lookup returns the card with the given ID.
+func (nb *Notebook) Lookup(id string) (Card, error) {
+	c, err := nb.byID(id)
+	if err != nil {
+		return Card{}, err
+	}
+	depth, position := c.place()
+	return c.snapshot(nb, depth, position), nil
+}
+
+// LookupPath returns the card at the given index path.
+func (nb *Notebook) LookupPath(path []int) (Card, error) {
+	c, err := nb.byPath(path)
+	if err != nil {
+		return Card{}, err
+	}
+	depth, position := c.place()
+	return c.snapshot(nb, depth, position), nil
+}
+
+// PathOf returns the index path to the card with the given ID.
+func (nb *Notebook) PathOf(id string) ([]int, error) {
+	c, err := nb.byID(id)
+	if err != nil {
+		return nil, err
+	}
+	return c.path(), nil
+}
+
+// Select sets the current card to the card with the given ID.
+func (nb *Notebook) Select(id string) error {
+	c, err := nb.byID(id)
+	if err != nil {
+		return err
+	}
+	nb.currentCard = c
+	return nil
+}
+
+// SelectPath sets the current card to the card at the given index path.
+func (nb *Notebook) SelectPath(path []int) error {
+	c, err := nb.byPath(path)
+	if err != nil {
+		return err
+	}
+	nb.currentCard = c
+	return nil
+}
+
+// WithCard calls fn with the card with the given ID as the current card, then moves the cursor back to where it was.
+//
+// Any of the methods that act on the current card may be used within fn. If the previously current card is no longer
+// part of the notebook afterwards, the cursor is left where fn put it.
+func (nb *Notebook) WithCard(id string, fn func() error) error {
+	c, err := nb.byID(id)
+	if err != nil {
+		return err
+	}
+	return nb.with(c, fn)
+}
+
+// WithCardAt calls fn with the card at the given index path as the current card, then moves the cursor back to where
+// it was. See WithCard.
+func (nb *Notebook) WithCardAt(path []int, fn func() error) error {
+	c, err := nb.byPath(path)
+	if err != nil {
+		return err
+	}
+	return nb.with(c, fn)
+}
+
+func (nb *Notebook) with(c *card, fn func() error) error {
+	previous := nb.currentCard
+	nb.currentCard = c
+	err := fn()
+	if nb.attached(previous) {
+		nb.currentCard = previous
+	}
+	return err
+}
@@ -0,0 +1,111 @@
+package notebook_test
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/makyo/mandelnote/notebook"
+)
+
+func TestAddressing(t *testing.T) {
+	Convey("When addressing cards by ID or path", t, func() {
+
+		nb := notebook.New("", "Addressing", "", "")
+		nb.AddCard("Act 1", "one", false)
+		nb.AddCard("Scene 1", "1.1", true)
+		nb.AddCard("Scene 2", "1.2", false)
+		nb.Exit()
+		nb.AddCard("Act 2", "two", false)
+
+		Convey("Every card has a unique ID", func() {
+			tree := nb.GetTree()
+			So(tree[0].ID, ShouldEqual, "c1")
+			So(tree[0].Children[0].ID, ShouldEqual, "c2")
+			So(tree[0].Children[1].ID, ShouldEqual, "c3")
+			So(tree[1].ID, ShouldEqual, "c4")
+			So(nb.CurrentID(), ShouldEqual, "c4")
+			So(nb.CurrentPath(), ShouldResemble, []int{1})
+		})
+
+		Convey("IDs survive saving and loading", func() {
+			So(nb.MarshalBody(), ShouldContainSubstring, "\n## Scene 2 {#c3}\n")
+			nb2, err := notebook.Unmarshal(nb.Marshal())
+			So(err, ShouldBeNil)
+			So(nb2.Select("c4"), ShouldBeNil)
+			So(nb2.GetTree(), ShouldResemble, nb.GetTree())
+
+			Convey("New cards do not reuse loaded IDs", func() {
+				nb2.AddCard("Act 3", "three", false)
+				So(nb2.CurrentID(), ShouldEqual, "c5")
+			})
+		})
+
+		Convey("Cards without IDs or with duplicate IDs are given new ones", func() {
+			nb2, err := notebook.Unmarshal("---\n---\n\n# Plain\n\n## Custom {#harbor}\n\n## Again {#harbor}\n\n# Set {a, b}\n")
+			So(err, ShouldBeNil)
+			tree := nb2.GetTree()
+			So(tree[0].ID, ShouldEqual, "c1")
+			So(tree[0].Children[0].ID, ShouldEqual, "harbor")
+			So(tree[0].Children[1].ID, ShouldEqual, "c2")
+			So(tree[1].Title, ShouldEqual, "Set {a, b}")
+			So(tree[1].ID, ShouldEqual, "c3")
+		})
+
+		Convey("Cards can be looked up", func() {
+			c, err := nb.Lookup("c3")
+			So(err, ShouldBeNil)
+			So(c.Title, ShouldEqual, "Scene 2")
+			c, err = nb.LookupPath([]int{0, 0})
+			So(err, ShouldBeNil)
+			So(c.Title, ShouldEqual, "Scene 1")
+			path, err := nb.PathOf("c3")
+			So(err, ShouldBeNil)
+			So(path, ShouldResemble, []int{0, 1})
+
+			_, err = nb.Lookup("bad-wolf")
+			So(err.Error(), ShouldEqual, "no card with ID bad-wolf")
+			_, err = nb.LookupPath([]int{0, 2})
+			So(err.Error(), ShouldEqual, "no card at path [0 2]")
+			_, err = nb.LookupPath([]int{})
+			So(err.Error(), ShouldEqual, "path must not be empty")
+		})
+
+		Convey("Cards can be selected", func() {
+			So(nb.Select("c2"), ShouldBeNil)
+			title, _ := nb.GetCard()
+			So(title, ShouldEqual, "Scene 1")
+			So(nb.SelectPath([]int{1}), ShouldBeNil)
+			title, _ = nb.GetCard()
+			So(title, ShouldEqual, "Act 2")
+			So(nb.Select("bad-wolf"), ShouldNotBeNil)
+		})
+
+		Convey("Cards can be changed without moving the cursor", func() {
+			err := nb.WithCard("c2", func() error {
+				nb.EditCard("Opening", "1.1")
+				return nil
+			})
+			So(err, ShouldBeNil)
+			So(nb.CurrentID(), ShouldEqual, "c4")
+			c, _ := nb.Lookup("c2")
+			So(c.Title, ShouldEqual, "Opening")
+
+			err = nb.WithCardAt([]int{0, 1}, func() error {
+				return nb.Promote()
+			})
+			So(err, ShouldBeNil)
+			So(nb.CurrentID(), ShouldEqual, "c4")
+			path, _ := nb.PathOf("c3")
+			So(path, ShouldResemble, []int{1})
+		})
+
+		Convey("The cursor stays put unless the current card is removed", func() {
+			err := nb.WithCard("c4", func() error {
+				return nb.Delete(false)
+			})
+			So(err, ShouldBeNil)
+			So(nb.CurrentID(), ShouldEqual, "c1")
+		})
+	})
+}
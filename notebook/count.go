@@ -0,0 +1,51 @@
+package notebook
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Counts holds the length of a piece of text. Characters include spaces but not line breaks, and paragraphs are
+// separated by blank lines.
+type Counts struct {
+	Words      int
+	Characters int
+	Paragraphs int
+}
+
+// Add returns the sum of two counts.
+func (c Counts) Add(other Counts) Counts {
+	return Counts{
+		Words:      c.Words + other.Words,
+		Characters: c.Characters + other.Characters,
+		Paragraphs: c.Paragraphs + other.Paragraphs,
+	}
+}
+
+// count measures a piece of text.
+func count(text string) Counts {
+	result := Counts{}
+	inParagraph := false
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimRight(line, "\r")
+		result.Characters += utf8.RuneCountInString(line)
+		words := len(strings.Fields(line))
+		result.Words += words
+		if words == 0 {
+			inParagraph = false
+		} else if !inParagraph {
+			inParagraph = true
+			result.Paragraphs++
+		}
+	}
+	return result
+}
+
+// Counts returns the total length of the bodies of every card in the notebook.
+func (nb *Notebook) Counts() Counts {
+	total := Counts{}
+	for _, c := range nb.GetTree() {
+		total = total.Add(c.Total)
+	}
+	return total
+}
@@ -0,0 +1,39 @@
+package notebook_test
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/makyo/mandelnote/notebook"
+)
+
+func TestCounts(t *testing.T) {
+	Convey("When counting words", t, func() {
+
+		nb := notebook.New("", "Counting", "", "")
+		nb.AddCard("Act 1", "One two three.\nFour.\n\n\nFive  six.", false)
+		nb.AddCard("Scene 1", "Seven eight.", true)
+		nb.AddCard("Scene 2", "Nine\r\n\r\nten ", false)
+		nb.Exit()
+		nb.AddCard("Act 2", "", false)
+
+		Convey("Each card's body is counted", func() {
+			tree := nb.GetTree()
+			So(tree[0].Counts, ShouldResemble, notebook.Counts{Words: 6, Characters: 29, Paragraphs: 2})
+			So(tree[0].Children[1].Counts, ShouldResemble, notebook.Counts{Words: 2, Characters: 8, Paragraphs: 2})
+			So(tree[1].Counts, ShouldResemble, notebook.Counts{})
+		})
+
+		Convey("Counts are totalled over each subtree", func() {
+			tree := nb.GetTree()
+			So(tree[0].Total, ShouldResemble, notebook.Counts{Words: 10, Characters: 49, Paragraphs: 5})
+			So(tree[0].Children[0].Total, ShouldResemble, tree[0].Children[0].Counts)
+		})
+
+		Convey("And over the whole notebook", func() {
+			So(nb.Counts(), ShouldResemble, notebook.Counts{Words: 10, Characters: 49, Paragraphs: 5})
+			So(notebook.New("", "Empty", "", "").Counts(), ShouldResemble, notebook.Counts{})
+		})
+	})
+}
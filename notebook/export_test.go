@@ -0,0 +1,14 @@
+package notebook
+
+// TempFile is the part of *os.File used while saving.
+type TempFile = tempFile
+
+// SetCreateTemp replaces the function used to create temporary files while saving, returning a function which puts
+// the original back.
+func SetCreateTemp(fn func(dir, pattern string) (TempFile, error)) func() {
+	original := createTemp
+	createTemp = fn
+	return func() {
+		createTemp = original
+	}
+}
@@ -0,0 +1,122 @@
+package notebook
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// tempFile is the part of *os.File used while saving.
+type tempFile interface {
+	Name() string
+	Write([]byte) (int, error)
+	Sync() error
+	Close() error
+}
+
+// createTemp creates the temporary file that a notebook is written to before it replaces the original.
+var createTemp = func(dir, pattern string) (tempFile, error) {
+	return ioutil.TempFile(dir, pattern)
+}
+
+// backupName returns the name of the nth backup of a file.
+func backupName(filename string, n int) string {
+	return fmt.Sprintf("%s~%d", filename, n)
+}
+
+// copyFile copies the contents and permissions of one file to another.
+func copyFile(from, to string) error {
+	in, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	out, err := os.OpenFile(to, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// rotateBackups shifts each backup of a file along by one, dropping the oldest, and keeps a copy of the file as the
+// newest backup.
+func rotateBackups(filename string, backups int) error {
+	os.Remove(backupName(filename, backups))
+	for i := backups - 1; i > 0; i-- {
+		if err := os.Rename(backupName(filename, i), backupName(filename, i+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	// Linking rather than renaming means that there is never a moment when the file itself is missing.
+	if err := os.Link(filename, backupName(filename, 1)); err != nil {
+		return copyFile(filename, backupName(filename, 1))
+	}
+	return nil
+}
+
+// writeFile safely replaces the contents of a file. The contents are written to a temporary file in the same directory
+// which is then renamed over the original, so a failure part way through never leaves a partially written file. If
+// backups is greater than zero, that many previous versions of the file are kept alongside it. The file is given the
+// mode passed, or if that is 0, keeps its own mode, with new files readable by everyone.
+func writeFile(filename string, contents []byte, backups int, mode os.FileMode) error {
+	if target, err := filepath.EvalSymlinks(filename); err == nil {
+		filename = target
+	}
+	info, err := os.Stat(filename)
+	exists := err == nil
+	if exists && info.IsDir() {
+		return fmt.Errorf("%s is a directory", filename)
+	}
+	if mode == 0 {
+		mode = 0644
+		if exists {
+			mode = info.Mode().Perm()
+		}
+	}
+
+	dir, base := filepath.Split(filename)
+	if dir == "" {
+		dir = "."
+	}
+	f, err := createTemp(dir, fmt.Sprintf(".%s.*.tmp", base))
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(contents); err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(f.Name(), mode)
+	}
+	if err == nil && exists && backups > 0 {
+		err = rotateBackups(filename, backups)
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), filename)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+
+	// Make sure the rename itself is on disk. Not every platform can sync a directory, so errors are ignored.
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package notebook_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/makyo/mandelnote/notebook"
+)
+
+// failingFile wraps a temporary file, failing at the given step.
+type failingFile struct {
+	*os.File
+	failAt string
+}
+
+func (f failingFile) Write(b []byte) (int, error) {
+	if f.failAt == "write" {
+		n, _ := f.File.Write(b[:len(b)/2])
+		return n, errors.New("disk full")
+	}
+	return f.File.Write(b)
+}
+
+func (f failingFile) Sync() error {
+	if f.failAt == "sync" {
+		return errors.New("sync failed")
+	}
+	return f.File.Sync()
+}
+
+func (f failingFile) Close() error {
+	err := f.File.Close()
+	if f.failAt == "close" {
+		return errors.New("close failed")
+	}
+	return err
+}
+
+func TestSave(t *testing.T) {
+	Convey("When saving a notebook", t, func() {
+
+		dir, err := ioutil.TempDir("", "mandelnote")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+		filename := filepath.Join(dir, "book.md")
+
+		nb := notebook.New(filename, "Saving", "", "")
+		nb.AddCard("Draft 1", "one", false)
+		So(nb.Save(), ShouldBeNil)
+
+		read := func(name string) string {
+			contents, err := ioutil.ReadFile(name)
+			if err != nil {
+				return ""
+			}
+			return string(contents)
+		}
+		names := func() []string {
+			files, _ := ioutil.ReadDir(dir)
+			result := []string{}
+			for _, f := range files {
+				result = append(result, f.Name())
+			}
+			return result
+		}
+
+		Convey("The file is replaced with no temporary files left over", func() {
+			So(nb.Dirty(), ShouldBeFalse)
+			So(read(filename), ShouldEqual, nb.Marshal())
+			nb.EditCard("Draft 2", "two")
+			So(nb.Save(), ShouldBeNil)
+			So(read(filename), ShouldContainSubstring, "Draft 2")
+			So(names(), ShouldResemble, []string{"book.md"})
+		})
+
+		Convey("The file's permissions are kept", func() {
+			So(os.Chmod(filename, 0600), ShouldBeNil)
+			So(nb.Save(), ShouldBeNil)
+			info, err := os.Stat(filename)
+			So(err, ShouldBeNil)
+			So(info.Mode().Perm(), ShouldEqual, os.FileMode(0600))
+		})
+
+		Convey("Saving through a symlink replaces the file it points to", func() {
+			link := filepath.Join(dir, "link.md")
+			So(os.Symlink(filename, link), ShouldBeNil)
+			nb.SetFile(link)
+			nb.EditCard("Draft 2", "two")
+			So(nb.Save(), ShouldBeNil)
+			target, err := os.Readlink(link)
+			So(err, ShouldBeNil)
+			So(target, ShouldEqual, filename)
+			So(read(filename), ShouldContainSubstring, "Draft 2")
+		})
+
+		Convey("Backups can be kept", func() {
+			nb.SetBackups(2)
+			nb.EditCard("Draft 2", "two")
+			So(nb.Save(), ShouldBeNil)
+			nb.EditCard("Draft 3", "three")
+			So(nb.Save(), ShouldBeNil)
+			nb.EditCard("Draft 4", "four")
+			So(nb.Save(), ShouldBeNil)
+			So(read(filename), ShouldContainSubstring, "Draft 4")
+			So(read(filename+"~1"), ShouldContainSubstring, "Draft 3")
+			So(read(filename+"~2"), ShouldContainSubstring, "Draft 2")
+			So(names(), ShouldResemble, []string{"book.md", "book.md~1", "book.md~2"})
+		})
+
+		Convey("A failure part way through leaves the original file alone", func() {
+			original := read(filename)
+			for _, step := range []string{"write", "sync", "close"} {
+				step := step
+				restore := notebook.SetCreateTemp(func(dir, pattern string) (notebook.TempFile, error) {
+					f, err := ioutil.TempFile(dir, pattern)
+					if err != nil {
+						return nil, err
+					}
+					return failingFile{f, step}, nil
+				})
+				nb.EditCard("Draft "+step, step)
+				err := nb.Save()
+				restore()
+				So(err, ShouldNotBeNil)
+				So(nb.Dirty(), ShouldBeTrue)
+				So(read(filename), ShouldEqual, original)
+				So(names(), ShouldResemble, []string{"book.md"})
+			}
+
+			restore := notebook.SetCreateTemp(func(dir, pattern string) (notebook.TempFile, error) {
+				return nil, errors.New("no space left on device")
+			})
+			defer restore()
+			So(nb.Save().Error(), ShouldEqual, "no space left on device")
+			So(read(filename), ShouldEqual, original)
+		})
+
+		Convey("A directory can't be saved over", func() {
+			nb.SetFile(dir)
+			So(nb.Save().Error(), ShouldEqual, dir+" is a directory")
+		})
+	})
+}
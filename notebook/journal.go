@@ -0,0 +1,180 @@
+package notebook
+
+import (
+	"fmt"
+	"time"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// DefaultUndoDepth is the number of changes that can be undone unless otherwise configured.
+const DefaultUndoDepth = 100
+
+// snapshot holds the state of a notebook at a point in time.
+type snapshot struct {
+	root        *card
+	current     []int
+	title       string
+	author      string
+	description string
+	revisions   []Revision
+	created     time.Time
+	modified    time.Time
+	meta        yaml.MapSlice
+	trash       []trashed
+	statuses    []string
+}
+
+// journal holds the undo and redo stacks for a notebook.
+type journal struct {
+	depth int
+	undo  []*snapshot
+	redo  []*snapshot
+}
+
+// clone returns a deep copy of the card and its following siblings, along with all of their children.
+func (c *card) clone(parent *card) *card {
+	var first, prev *card
+	for curr := c; curr != nil; curr = curr.next {
+		cp := *curr
+		cp.parent = parent
+		cp.prev = prev
+		cp.next = nil
+		if curr.firstChild != nil {
+			cp.firstChild = curr.firstChild.clone(&cp)
+		}
+		if prev == nil {
+			first = &cp
+		} else {
+			prev.next = &cp
+		}
+		prev = &cp
+	}
+	return first
+}
+
+// path returns the index path from the root to the card.
+func (c *card) path() []int {
+	path := []int{}
+	for curr := c; curr.parent != nil; curr = curr.parent {
+		i := 0
+		for sibling := curr.parent.firstChild; sibling != curr; sibling = sibling.next {
+			i++
+		}
+		path = append([]int{i}, path...)
+	}
+	return path
+}
+
+// at returns the descendant of the card at the given index path, or nil if there is none.
+func (c *card) at(path []int) *card {
+	curr := c
+	for _, i := range path {
+		curr = curr.firstChild
+		for ; i > 0 && curr != nil; i-- {
+			curr = curr.next
+		}
+		if curr == nil {
+			return nil
+		}
+	}
+	return curr
+}
+
+func (nb *Notebook) snapshot() *snapshot {
+	root := &card{}
+	if nb.root.firstChild != nil {
+		root.firstChild = nb.root.firstChild.clone(root)
+	}
+	return &snapshot{
+		root:        root,
+		current:     nb.currentCard.path(),
+		title:       nb.Title,
+		author:      nb.Author,
+		description: nb.Description,
+		revisions:   append([]Revision{}, nb.Revisions...),
+		created:     nb.Created,
+		modified:    nb.Modified,
+		meta:        nb.meta,
+		trash:       cloneTrash(nb.trash),
+		statuses:    nb.statuses,
+	}
+}
+
+func (nb *Notebook) restore(s *snapshot) {
+	nb.root = s.root
+	nb.currentCard = nb.root.at(s.current)
+	if nb.currentCard == nil {
+		nb.currentCard = nb.root
+	}
+	nb.Title = s.title
+	nb.Author = s.author
+	nb.Description = s.description
+	nb.Revisions = s.revisions
+	nb.Created = s.created
+	nb.Modified = s.modified
+	nb.meta = s.meta
+	nb.trash = s.trash
+	nb.statuses = s.statuses
+	nb.dirty = true
+}
+
+// record saves the state of the notebook before a change so that the change may be undone.
+func (nb *Notebook) record() {
+	if nb.journal.depth <= 0 {
+		return
+	}
+	nb.journal.undo = append(nb.journal.undo, nb.snapshot())
+	if len(nb.journal.undo) > nb.journal.depth {
+		nb.journal.undo = nb.journal.undo[len(nb.journal.undo)-nb.journal.depth:]
+	}
+	nb.journal.redo = nil
+}
+
+// SetUndoDepth sets the number of changes that can be undone, discarding the oldest if there are too many.
+func (nb *Notebook) SetUndoDepth(depth int) {
+	if depth < 0 {
+		depth = 0
+	}
+	nb.journal.depth = depth
+	if len(nb.journal.undo) > depth {
+		nb.journal.undo = nb.journal.undo[len(nb.journal.undo)-depth:]
+	}
+	if len(nb.journal.redo) > depth {
+		nb.journal.redo = nb.journal.redo[len(nb.journal.redo)-depth:]
+	}
+}
+
+// CanUndo returns whether or not there are changes that can be undone.
+func (nb *Notebook) CanUndo() bool {
+	return len(nb.journal.undo) > 0
+}
+
+// CanRedo returns whether or not there are undone changes that can be redone.
+func (nb *Notebook) CanRedo() bool {
+	return len(nb.journal.redo) > 0
+}
+
+// Undo reverts the most recent change to the notebook, including the position of the current card.
+func (nb *Notebook) Undo() error {
+	if !nb.CanUndo() {
+		return fmt.Errorf("nothing to undo")
+	}
+	last := nb.journal.undo[len(nb.journal.undo)-1]
+	nb.journal.undo = nb.journal.undo[:len(nb.journal.undo)-1]
+	nb.journal.redo = append(nb.journal.redo, nb.snapshot())
+	nb.restore(last)
+	return nil
+}
+
+// Redo reapplies the most recently undone change to the notebook.
+func (nb *Notebook) Redo() error {
+	if !nb.CanRedo() {
+		return fmt.Errorf("nothing to redo")
+	}
+	last := nb.journal.redo[len(nb.journal.redo)-1]
+	nb.journal.redo = nb.journal.redo[:len(nb.journal.redo)-1]
+	nb.journal.undo = append(nb.journal.undo, nb.snapshot())
+	nb.restore(last)
+	return nil
+}
@@ -0,0 +1,142 @@
+package notebook_test
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/makyo/mandelnote/notebook"
+)
+
+func TestJournal(t *testing.T) {
+	Convey("When undoing and redoing changes", t, func() {
+
+		nb := notebook.New("", "Journal", "", "")
+		nb.AddCard("Act 1", "one", false)
+		nb.AddCard("Scene 1", "1.1", true)
+		nb.AddCard("Scene 2", "1.2", false)
+		nb.Exit()
+		nb.AddCard("Act 2", "two", false)
+		before := nb.MarshalBody()
+
+		Convey("Nothing to undo or redo on a new notebook", func() {
+			nb2 := notebook.New("", "Empty", "", "")
+			So(nb2.CanUndo(), ShouldBeFalse)
+			So(nb2.Undo().Error(), ShouldEqual, "nothing to undo")
+			So(nb2.Redo().Error(), ShouldEqual, "nothing to redo")
+		})
+
+		Convey("Adding cards can be undone and redone", func() {
+			So(nb.Undo(), ShouldBeNil)
+			So(nb.GetTree(), ShouldHaveLength, 1)
+			title, _ := nb.GetCard()
+			So(title, ShouldEqual, "Act 1")
+			So(nb.Redo(), ShouldBeNil)
+			So(nb.MarshalBody(), ShouldEqual, before)
+			title, _ = nb.GetCard()
+			So(title, ShouldEqual, "Act 2")
+		})
+
+		Convey("Edits can be undone", func() {
+			nb.EditCard("Act II", "deux")
+			So(nb.Undo(), ShouldBeNil)
+			title, body := nb.GetCard()
+			So(title, ShouldEqual, "Act 2")
+			So(body, ShouldEqual, "two")
+
+			Convey("Unchanged edits are not recorded", func() {
+				nb.EditCard("Act 2", "two")
+				So(nb.Undo(), ShouldBeNil)
+				So(nb.GetTree(), ShouldHaveLength, 1)
+			})
+		})
+
+		Convey("Structural changes restore the tree and current card", func() {
+			nb.Merge(-1)
+			So(nb.GetTree(), ShouldHaveLength, 1)
+			So(nb.Undo(), ShouldBeNil)
+			So(nb.MarshalBody(), ShouldEqual, before)
+			title, _ := nb.GetCard()
+			So(title, ShouldEqual, "Act 2")
+
+			nb.Cycle(-1)
+			So(nb.Delete(true), ShouldBeNil)
+			So(nb.Undo(), ShouldBeNil)
+			So(nb.MarshalBody(), ShouldEqual, before)
+			title, _ = nb.GetCard()
+			So(title, ShouldEqual, "Act 1")
+
+			nb.Enter()
+			nb.Cycle(1)
+			So(nb.Promote(), ShouldBeNil)
+			nb.Move(1)
+			So(nb.Undo(), ShouldBeNil)
+			So(nb.Undo(), ShouldBeNil)
+			So(nb.MarshalBody(), ShouldEqual, before)
+			title, _ = nb.GetCard()
+			So(title, ShouldEqual, "Scene 2")
+
+			So(nb.PromoteAll(true), ShouldBeNil)
+			So(nb.Undo(), ShouldBeNil)
+			So(nb.MarshalBody(), ShouldEqual, before)
+		})
+
+		Convey("Metadata changes can be undone", func() {
+			nb.SetMetadata("New title", "New author", "New description")
+			So(nb.Undo(), ShouldBeNil)
+			So(nb.Title, ShouldEqual, "Journal")
+			So(nb.Author, ShouldEqual, "")
+		})
+
+		Convey("Revisions are undone along with the metadata edit they were made with", func() {
+			modified := nb.Modified
+			nb.SetMetadata("New title", "New author", "New description")
+			nb.AddRevision("Renamed")
+			So(nb.Revisions, ShouldHaveLength, 1)
+			So(nb.Undo(), ShouldBeNil)
+			So(nb.Revisions, ShouldBeEmpty)
+			So(nb.Modified, ShouldEqual, modified)
+			So(nb.Undo(), ShouldBeNil)
+			So(nb.Title, ShouldEqual, "Journal")
+
+			Convey("And redone", func() {
+				So(nb.Redo(), ShouldBeNil)
+				So(nb.Redo(), ShouldBeNil)
+				So(nb.Title, ShouldEqual, "New title")
+				So(nb.Revisions[0].Message, ShouldEqual, "Renamed")
+			})
+		})
+
+		Convey("No-op moves and merges are not recorded", func() {
+			nb.Move(1)
+			nb.Merge(1)
+			So(nb.Undo(), ShouldBeNil)
+			So(nb.GetTree(), ShouldHaveLength, 1)
+		})
+
+		Convey("New changes clear the redo journal", func() {
+			So(nb.Undo(), ShouldBeNil)
+			So(nb.CanRedo(), ShouldBeTrue)
+			nb.AddCard("Act 3", "three", false)
+			So(nb.CanRedo(), ShouldBeFalse)
+		})
+
+		Convey("The journal is bounded by depth", func() {
+			nb.SetUndoDepth(2)
+			So(nb.Undo(), ShouldBeNil)
+			So(nb.Undo(), ShouldBeNil)
+			So(nb.CanUndo(), ShouldBeFalse)
+			So(nb.Undo().Error(), ShouldEqual, "nothing to undo")
+
+			nb.SetUndoDepth(0)
+			nb.AddCard("Unrecorded", "", false)
+			So(nb.CanUndo(), ShouldBeFalse)
+		})
+
+		Convey("Loading a notebook is not recorded", func() {
+			nb2, err := notebook.Unmarshal(nb.Marshal())
+			So(err, ShouldBeNil)
+			So(nb2.CanUndo(), ShouldBeFalse)
+		})
+	})
+}
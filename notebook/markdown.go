@@ -0,0 +1,147 @@
+package notebook
+
+import (
+	"strings"
+
+	"github.com/makyo/mandelnote/internal/markdown"
+)
+
+// The kinds of block that a line of Markdown can belong to, as far as telling headers apart from bodies goes.
+const (
+	blockNone = iota
+	blockParagraph
+	blockCode
+	blockOther
+)
+
+// blockState follows the block structure of a Markdown document line by line. It only tracks as much as is needed to
+// tell whether a line is a header: whether we are in a fenced code block, and what kind of block the previous line
+// belonged to, since only a line following a paragraph can be a setext header underline.
+type blockState struct {
+	fence string
+	block int
+}
+
+// atxHeader parses a header of the form `## Title`. Unlike CommonMark, any number of `#` is allowed, so that cards may
+// be nested as deeply as needed.
+func atxHeader(line string) (int, string, bool) {
+	rest, ok := markdown.Unindent(line)
+	if !ok {
+		return 0, "", false
+	}
+	depth := len(rest) - len(strings.TrimLeft(rest, "#"))
+	if depth == 0 {
+		return 0, "", false
+	}
+	rest = rest[depth:]
+	if rest == "" {
+		return depth, "", true
+	}
+	if rest[0] != ' ' && rest[0] != '\t' {
+		return 0, "", false
+	}
+	return depth, rest[1:], true
+}
+
+// setextUnderline returns the depth of the header that the line would underline, or 0 if it is not an underline.
+func setextUnderline(line string) int {
+	rest, ok := markdown.Unindent(line)
+	if !ok {
+		return 0
+	}
+	rest = strings.TrimRight(rest, " \t")
+	switch {
+	case rest == "":
+		return 0
+	case strings.Trim(rest, "=") == "":
+		return 1
+	case strings.Trim(rest, "-") == "":
+		return 2
+	}
+	return 0
+}
+
+// escapable returns whether the line would be read as a header were it not for any backslashes at its start, along
+// with the position of those backslashes and how many there are.
+func escapable(line string, paragraph bool) (int, int, bool) {
+	rest, ok := markdown.Unindent(line)
+	if !ok {
+		return 0, 0, false
+	}
+	at := len(line) - len(rest)
+	slashes := len(rest) - len(strings.TrimLeft(rest, "\\"))
+	rest = rest[slashes:]
+	if _, _, ok := atxHeader(rest); ok {
+		return at, slashes, true
+	}
+	if paragraph && setextUnderline(rest) > 0 {
+		return at, slashes, true
+	}
+	return 0, 0, false
+}
+
+// containerMarker returns whether the line starts a list item or block quote.
+func containerMarker(line string) bool {
+	rest, ok := markdown.Unindent(line)
+	if !ok || rest == "" {
+		return false
+	}
+	switch rest[0] {
+	case '>':
+		return true
+	case '-', '*', '+':
+		return len(rest) == 1 || rest[1] == ' ' || rest[1] == '\t'
+	}
+	digits := len(rest) - len(strings.TrimLeft(rest, "0123456789"))
+	if digits == 0 || digits > 9 || digits == len(rest) || (rest[digits] != '.' && rest[digits] != ')') {
+		return false
+	}
+	return digits+1 == len(rest) || rest[digits+1] == ' ' || rest[digits+1] == '\t'
+}
+
+// next updates the state with a line that is not a header.
+func (s *blockState) next(line string) {
+	if s.fence != "" {
+		if markdown.ClosesFence(s.fence, line) {
+			s.fence = ""
+		}
+		return
+	}
+	if fence, _ := markdown.OpenFence(line); fence != "" {
+		s.fence = fence
+		s.block = blockNone
+		return
+	}
+	width, _ := markdown.Indentation(line)
+	switch {
+	case strings.TrimSpace(line) == "":
+		s.block = blockNone
+	case width > 3 && (s.block == blockNone || s.block == blockCode):
+		s.block = blockCode
+	case markdown.ThematicBreak(line):
+		s.block = blockNone
+	case containerMarker(line):
+		s.block = blockOther
+	case s.block == blockNone || s.block == blockCode:
+		s.block = blockParagraph
+	}
+}
+
+// escapeBody escapes the lines of a card's body that would otherwise be read as headers and closes any fenced code
+// block left open, so that the body is read back in unchanged.
+func escapeBody(body string) string {
+	state := blockState{}
+	lines := strings.Split(body, "\n")
+	for i, line := range lines {
+		if state.fence == "" {
+			if at, _, ok := escapable(line, state.block == blockParagraph); ok {
+				lines[i] = line[:at] + "\\" + line[at:]
+			}
+		}
+		state.next(lines[i])
+	}
+	if state.fence != "" {
+		lines = append(lines, state.fence)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -3,118 +3,326 @@ package notebook
 import (
 	"fmt"
 	"io/ioutil"
+	"strconv"
 	"strings"
 
 	yaml "gopkg.in/yaml.v2"
 )
 
+// attributes holds the contents of the attribute block at the end of a header, such as `{#c1 .tag target=2000}`.
+type attributes struct {
+	id     string
+	tags   []string
+	target int
+	status string
+}
+
+// validValue returns whether a status or tag may be stored in a header attribute block.
+func validValue(value string) bool {
+	return value != "" && !strings.ContainsAny(value, " \t\r\n{}=#") && !strings.HasPrefix(value, ".")
+}
+
+// splitHeader separates the title of a card from the attribute block at the end of its header, if it has one.
+func splitHeader(text string) (string, attributes) {
+	attrs := attributes{}
+	start := strings.LastIndex(text, " {")
+	if start == -1 || !strings.HasSuffix(text, "}") {
+		return text, attrs
+	}
+	fields := strings.Fields(text[start+2 : len(text)-1])
+	if len(fields) == 0 {
+		return text, attrs
+	}
+	for _, field := range fields {
+		switch {
+		case len(field) > 1 && field[0] == '#' && !strings.ContainsAny(field, "{}"):
+			attrs.id = field[1:]
+		case len(field) > 1 && field[0] == '.' && validValue(field[1:]):
+			attrs.tags = append(attrs.tags, field[1:])
+		case strings.HasPrefix(field, "target="):
+			target, err := strconv.Atoi(strings.TrimPrefix(field, "target="))
+			if err != nil || target <= 0 {
+				return text, attributes{}
+			}
+			attrs.target = target
+		case strings.HasPrefix(field, "status=") && validValue(strings.TrimPrefix(field, "status=")):
+			attrs.status = strings.TrimPrefix(field, "status=")
+		default:
+			return text, attributes{}
+		}
+	}
+	return text[:start], attrs
+}
+
+// header generates the text of the card's header, including its attribute block. Titles are kept to a single line.
+func (c *card) header() string {
+	title := strings.Replace(strings.Replace(c.title, "\r\n", " ", -1), "\n", " ", -1)
+	fields := []string{}
+	if c.id != "" {
+		fields = append(fields, "#"+c.id)
+	}
+	for _, tag := range c.tags {
+		fields = append(fields, "."+tag)
+	}
+	if c.target > 0 {
+		fields = append(fields, fmt.Sprintf("target=%d", c.target))
+	}
+	if c.status != "" {
+		fields = append(fields, "status="+c.status)
+	}
+	if len(fields) == 0 {
+		return title
+	}
+	return fmt.Sprintf("%s {%s}", title, strings.Join(fields, " "))
+}
+
+// marshalBody generates the Markdown for the card's body, escaped so that it will be read back in the same way.
+func (c *card) marshalBody() string {
+	lines := strings.Split(c.body, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, "\r")
+	}
+	return escapeBody(strings.Trim(strings.Join(lines, "\n"), "\n"))
+}
+
 // Marshal generates a Markdown string of a card and all its children, with its title in a header.
 func (c *card) Marshal(depth int) string {
+	return c.marshalDepths(depth, 1, 0)
+}
+
+// marshalDepths generates a Markdown string of a card and its children, leaving out those which are not between the
+// depths min and max. Headers are renumbered so that cards at min become top-level cards.
+func (c *card) marshalDepths(depth, min, max int) string {
 	body := ""
-	curr := c
-	for curr != nil {
-		body += fmt.Sprintf("\n%s %s\n\n%s\n", strings.Repeat("#", depth), curr.title, curr.body)
-		if curr.firstChild != nil {
-			body += curr.firstChild.Marshal(depth + 1)
+	for curr := c; curr != nil; curr = curr.next {
+		if depth >= min {
+			body += fmt.Sprintf("\n%s %s\n\n%s%s\n", strings.Repeat("#", depth-min+1), curr.header(),
+				curr.marshalProperties(), curr.marshalBody())
+		}
+		if curr.firstChild != nil && (max == 0 || depth < max) {
+			body += curr.firstChild.marshalDepths(depth+1, min, max)
 		}
-		curr = curr.next
 	}
 	return body
 }
 
-// MarshalHeader generates a yaml block of the notebook's metadata
+// MarshalHeader generates a yaml block of the notebook's metadata, along with any other front matter read in with it.
 func (nb *Notebook) MarshalHeader() ([]byte, error) {
-	return yaml.Marshal(nb)
+	return yaml.Marshal(nb.frontMatter())
 }
 
 func (nb *Notebook) MarshalBody() string {
 	return nb.root.firstChild.Marshal(1)
 }
 
+// MarshalDepths generates the Markdown for only the cards with depths between min and max, inclusive, with top-level
+// cards at depth 1. Cards above min are left out but their children are kept, becoming top-level cards themselves. A
+// max of 0 means there is no limit.
+func (nb *Notebook) MarshalDepths(min, max int) string {
+	if min < 1 {
+		min = 1
+	}
+	return nb.root.firstChild.marshalDepths(1, min, max)
+}
+
 func (nb *Notebook) Marshal() string {
 	header, _ := nb.MarshalHeader()
 	return fmt.Sprintf("---\n%s\n---\n%s", header, nb.MarshalBody())
 }
 
-func Unmarshal(contents string) (*Notebook, error) {
-	parts := strings.SplitN(contents, "---\n", 3)
-	if len(parts) != 3 {
-		return nil, fmt.Errorf("malformed notebook; must contain metadata block and body")
+// splitFrontMatter separates the YAML block at the start of a notebook from the Markdown that follows it.
+func splitFrontMatter(contents string) (string, string, error) {
+	lines := strings.SplitAfter(contents, "\n")
+	if lines[0] != "---\n" {
+		return "", "", fmt.Errorf("malformed notebook; must contain metadata block and body")
 	}
-	nb := New("", "", "", "")
-	err := yaml.Unmarshal([]byte(parts[1]), nb)
-	if err != nil {
-		return nil, err
+	offset := len(lines[0])
+	for _, line := range lines[1:] {
+		if strings.TrimRight(line, "\n") == "---" {
+			return contents[len(lines[0]):offset], contents[offset+len(line):], nil
+		}
+		offset += len(line)
 	}
-	lines := strings.Split(parts[2], "\n")
-	haveValidFirst := false
-	currDepth := 1
-	for _, line := range lines {
-		currTitle, currBody := nb.GetCard()
-		if line == "" && len(currBody) == 0 {
-			continue
+	return "", "", fmt.Errorf("malformed notebook; must contain metadata block and body")
+}
+
+// parser builds the tree of cards from the Markdown body of a notebook.
+type parser struct {
+	root      *card
+	current   *card
+	depth     int
+	lines     []string
+	paragraph int
+	state     blockState
+}
+
+// finish sets the properties and body of the current card from the lines read since its header.
+func (p *parser) finish() error {
+	lines := p.lines
+	p.lines = nil
+	if p.current == p.root {
+		if strings.TrimSpace(strings.Join(lines, "\n")) != "" {
+			return fmt.Errorf("malformed notebook; cannot have body without header")
 		}
-		if len(line) > 0 && line[0] == '#' {
-			if nb.currentCard != nil {
-				nb.EditCard(nb.currentCard.title, strings.TrimRight(nb.currentCard.body, "\n"))
-			}
-			lineParts := strings.SplitN(line, " ", 2)
-			if len(lineParts) != 2 {
-				return nil, fmt.Errorf("malformed notebook; title must contain depth marker and text")
-			}
-			depth, title := lineParts[0], lineParts[1]
-			if !haveValidFirst && len(depth) > 1 {
-				return nil, fmt.Errorf("malformed notebook; must start at header depth 1, found %s", line)
-			}
-			haveValidFirst = true
-			if len(depth) == currDepth {
-				nb.AddCard(title, "", false)
-			} else if len(depth) == currDepth+1 {
-				nb.AddCard(title, "", true)
-				currDepth++
-			} else if len(depth) < currDepth {
-				for currDepth != len(depth) {
-					currDepth--
-					nb.Exit()
+		return nil
+	}
+	if props, rest, ok := splitProperties(lines); ok {
+		p.current.properties = props
+		lines = rest
+	}
+	p.current.body = strings.Trim(strings.Join(lines, "\n"), "\n")
+	return nil
+}
+
+// addCard starts a new card at the given depth, as a sibling of the current card or of one of its ancestors, or as its
+// first child.
+func (p *parser) addCard(depth int, text, line string) error {
+	if err := p.finish(); err != nil {
+		return err
+	}
+	title, attrs := splitHeader(text)
+	c := &card{
+		id:     attrs.id,
+		title:  title,
+		target: attrs.target,
+		status: attrs.status,
+		tags:   uniqueTags(attrs.tags),
+	}
+	switch {
+	case p.current == p.root && depth != 1:
+		return fmt.Errorf("malformed notebook; must start at header depth 1, found %s", line)
+	case depth == p.depth+1:
+		c.parent = p.current
+		p.current.firstChild = c
+	case depth <= p.depth:
+		prev := p.current
+		for ; p.depth > depth; p.depth-- {
+			prev = prev.parent
+		}
+		c.parent = prev.parent
+		c.prev = prev
+		prev.next = c
+	default:
+		return fmt.Errorf("malformed notebook; header depths must increase by 1")
+	}
+	p.current = c
+	p.depth = depth
+	p.state = blockState{}
+	return nil
+}
+
+// parse reads each line of the body, starting new cards at headers that are not within code blocks.
+func (p *parser) parse(body string) error {
+	for _, line := range strings.Split(body, "\n") {
+		if p.state.fence == "" {
+			if depth, text, ok := atxHeader(line); ok {
+				if err := p.addCard(depth, text, line); err != nil {
+					return err
 				}
-				nb.AddCard(title, "", false)
-			} else {
-				return nil, fmt.Errorf("malformed notebook; header depths must increase by 1")
+				continue
 			}
-		} else {
-			if !haveValidFirst {
-				return nil, fmt.Errorf("malformed notebook; cannot have body without header")
-			}
-			if len(currBody) > 0 {
-				if len(line) == 0 {
-					nb.EditCard(currTitle, currBody+"\n")
-				} else {
-					nb.EditCard(currTitle, strings.TrimRight(fmt.Sprintf("%s\n%s", currBody, line), "\n"))
+			if depth := setextUnderline(line); depth > 0 && p.state.block == blockParagraph {
+				titleLines := p.lines[p.paragraph:]
+				for i, titleLine := range titleLines {
+					titleLines[i] = strings.TrimSpace(titleLine)
+				}
+				p.lines = p.lines[:p.paragraph]
+				if err := p.addCard(depth, strings.Join(titleLines, " "), line); err != nil {
+					return err
 				}
-			} else {
-				nb.EditCard(currTitle, line)
+				continue
 			}
 		}
+		unescaped := line
+		if p.state.fence == "" {
+			if at, slashes, ok := escapable(line, p.state.block == blockParagraph); ok && slashes > 0 {
+				unescaped = line[:at] + line[at+1:]
+			}
+		}
+		wasParagraph := p.state.block == blockParagraph
+		p.lines = append(p.lines, unescaped)
+		p.state.next(line)
+		if p.state.block == blockParagraph && !wasParagraph {
+			p.paragraph = len(p.lines) - 1
+		}
 	}
-	if nb.currentCard != nil {
-		nb.EditCard(nb.currentCard.title, strings.TrimRight(nb.currentCard.body, "\n"))
+	return p.finish()
+}
+
+// Unmarshal reads a notebook from a YAML metadata block followed by Markdown, in which each header starts a card.
+// Headers may be ATX (`# Title`) or setext (a title underlined with `===` or `---`); lines within code blocks and
+// lines escaped with a backslash are never headers.
+func Unmarshal(contents string) (*Notebook, error) {
+	header, body, err := splitFrontMatter(strings.Replace(contents, "\r\n", "\n", -1))
+	if err != nil {
+		return nil, err
+	}
+	nb := New("", "", "", "")
+	err = yaml.Unmarshal([]byte(header), nb)
+	if err != nil {
+		return nil, err
+	}
+	err = yaml.Unmarshal([]byte(header), &nb.meta)
+	if err != nil {
+		return nil, err
+	}
+	var stored struct {
+		Trash    []trashEntry
+		Statuses []string
+	}
+	err = yaml.Unmarshal([]byte(header), &stored)
+	if err != nil {
+		return nil, err
+	}
+	if err = nb.readTrash(stored.Trash); err != nil {
+		return nil, err
+	}
+	for _, status := range stored.Statuses {
+		if !validValue(status) {
+			return nil, fmt.Errorf("malformed notebook; invalid status %q", status)
+		}
+	}
+	nb.statuses = stored.Statuses
+	p := &parser{
+		root:    nb.root,
+		current: nb.root,
+	}
+	if err = p.parse(body); err != nil {
+		return nil, err
+	}
+	nb.assignIDs()
+	nb.currentCard = nb.root
+	if nb.root.firstChild != nil {
+		nb.currentCard = nb.root.firstChild
 	}
-	nb.currentCard = nb.root.firstChild
 	return nb, nil
 }
 
+// File returns the name of the file to which the notebook is saved.
+func (nb *Notebook) File() string {
+	return nb.filename
+}
+
 // SetFile changes the file to which the notebook is saved.
 func (nb *Notebook) SetFile(filename string) {
 	nb.filename = filename
 }
 
-// Save saves the notebook's contents to disk.
+// SetBackups sets the number of previous versions of the file to keep when saving, as file.md~1, file.md~2, and so on.
+func (nb *Notebook) SetBackups(backups int) {
+	nb.backups = backups
+}
+
+// Save saves the notebook's contents to disk, replacing the file only once the new contents have been written.
 func (nb *Notebook) Save() error {
-	err := ioutil.WriteFile(nb.filename, []byte(nb.Marshal()), 0644)
+	err := writeFile(nb.filename, []byte(nb.Marshal()), nb.backups, 0)
 	if err != nil {
 		return err
 	}
 	nb.dirty = false
+
+	// Now that everything is saved, any recovery file is out of date.
+	nb.DiscardRecovery()
 	return nil
 }
 
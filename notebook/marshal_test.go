@@ -0,0 +1,199 @@
+package notebook_test
+
+import (
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/quick"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/makyo/mandelnote/notebook"
+)
+
+// fragments are bits of Markdown that are easy to mistake for headers or that otherwise make parsing tricky.
+var fragments = []string{
+	"",
+	" ",
+	"Plain text.",
+	"# Looks like a header",
+	"## Looks like a deeper header",
+	"   # Indented header",
+	"    # Indented code",
+	"\t# Tabbed code",
+	"#hashtag",
+	"#",
+	"\\# Already escaped",
+	"\\\\# Doubly escaped",
+	"```",
+	"```sh",
+	"~~~",
+	"````",
+	"# comment in code",
+	"---",
+	"===",
+	"\\---",
+	"- - -",
+	"***",
+	"- list item",
+	"1. numbered",
+	"> quote",
+	"Title {#c1}",
+	"{#looks-like-attributes}",
+	"line\r",
+	"crlf\r\nline",
+}
+
+// randomNotebook wraps a notebook so that testing/quick can generate random ones.
+type randomNotebook struct {
+	nb *notebook.Notebook
+}
+
+func randomText(r *rand.Rand, size int) string {
+	parts := make([]string, r.Intn(size+1))
+	for i := range parts {
+		parts[i] = fragments[r.Intn(len(fragments))]
+	}
+	return strings.Join(parts, []string{"\n", "\n\n"}[r.Intn(2)])
+}
+
+func (randomNotebook) Generate(r *rand.Rand, size int) reflect.Value {
+	nb := notebook.New("", randomText(r, 2), randomText(r, 1), randomText(r, 3))
+	for i := 0; i < size; i++ {
+		switch r.Intn(4) {
+		case 0:
+			nb.Exit()
+		case 1:
+			nb.AddCard(randomText(r, 2), randomText(r, size), true)
+		default:
+			nb.AddCard(randomText(r, 2), randomText(r, size), false)
+		}
+	}
+	return reflect.ValueOf(randomNotebook{nb})
+}
+
+// flatten lists the IDs and titles of every card in the tree, along with their depth.
+func flatten(cards []notebook.Card, depth int) []string {
+	result := []string{}
+	for _, c := range cards {
+		title := strings.Replace(strings.Replace(c.Title, "\r\n", " ", -1), "\n", " ", -1)
+		result = append(result, strings.Repeat(">", depth)+c.ID+" "+title)
+		result = append(result, flatten(c.Children, depth+1)...)
+	}
+	return result
+}
+
+func TestMarshal(t *testing.T) {
+	Convey("When reading and writing Markdown", t, func() {
+
+		Convey("Headers within code blocks are part of the body", func() {
+			nb, err := notebook.Unmarshal("---\n---\n\n# Script\n\n```sh\n# comment\necho hi\n```\n\n~~~\n# also\n~~~~\n\n    # indented\n\n## Child\n\nchild\n")
+			So(err, ShouldBeNil)
+			tree := nb.GetTree()
+			So(tree, ShouldHaveLength, 1)
+			So(tree[0].Body, ShouldEqual, "```sh\n# comment\necho hi\n```\n\n~~~\n# also\n~~~~\n\n    # indented")
+			So(tree[0].Children[0].Title, ShouldEqual, "Child")
+		})
+
+		Convey("Unclosed code blocks are closed when written", func() {
+			nb := notebook.New("", "Unclosed", "", "")
+			nb.AddCard("Code", "```\n# comment", false)
+			nb.AddCard("After", "after", false)
+			nb2, err := notebook.Unmarshal(nb.Marshal())
+			So(err, ShouldBeNil)
+			So(nb2.GetTree(), ShouldHaveLength, 2)
+			So(nb2.GetTree()[0].Body, ShouldEqual, "```\n# comment\n```")
+		})
+
+		Convey("A # must be followed by a space to start a header", func() {
+			nb, err := notebook.Unmarshal("---\n---\n\n# Tags\n\n#hashtag #another\n\n#\n")
+			So(err, ShouldBeNil)
+			tree := nb.GetTree()
+			So(tree, ShouldHaveLength, 2)
+			So(tree[0].Body, ShouldEqual, "#hashtag #another")
+			So(tree[1].Title, ShouldEqual, "")
+		})
+
+		Convey("Setext headers start cards", func() {
+			nb, err := notebook.Unmarshal("---\n---\n\nPart One\n========\n\nIntro.\n\nChapter\none\n-------\n\nText.\n\n---\n\nMore text.\n")
+			So(err, ShouldBeNil)
+			tree := nb.GetTree()
+			So(tree, ShouldHaveLength, 1)
+			So(tree[0].Title, ShouldEqual, "Part One")
+			So(tree[0].Body, ShouldEqual, "Intro.")
+			So(tree[0].Children[0].Title, ShouldEqual, "Chapter one")
+			So(tree[0].Children[0].Body, ShouldEqual, "Text.\n\n---\n\nMore text.")
+		})
+
+		Convey("But not after lists, quotes or code", func() {
+			nb, err := notebook.Unmarshal("---\n---\n\n# Lists\n\n- item\n---\n\n> quote\n===\n\n    code\n---\n")
+			So(err, ShouldBeNil)
+			So(nb.GetTree(), ShouldHaveLength, 1)
+		})
+
+		Convey("Bodies that look like headers are escaped", func() {
+			nb := notebook.New("", "Escaping", "", "")
+			nb.AddCard("Card", "# Not a card\n\\# Escaped\nParagraph\n---\nParagraph\n===", false)
+			marshalled := nb.MarshalBody()
+			So(marshalled, ShouldEqual, "\n# Card {#c1}\n\n\\# Not a card\n\\\\# Escaped\nParagraph\n\\---\nParagraph\n\\===\n")
+			nb2, err := notebook.Unmarshal(nb.Marshal())
+			So(err, ShouldBeNil)
+			So(nb2.GetTree(), ShouldHaveLength, 1)
+			_, body := nb2.GetCard()
+			So(body, ShouldEqual, "# Not a card\n\\# Escaped\nParagraph\n---\nParagraph\n===")
+		})
+
+		Convey("Front matter ends at the first line of just ---", func() {
+			nb, err := notebook.Unmarshal("---\ntitle: Rules---\n---\n# Rule\n\nabove\n\n---\n\nbelow\n")
+			So(err, ShouldBeNil)
+			So(nb.Title, ShouldEqual, "Rules---")
+			_, body := nb.GetCard()
+			So(body, ShouldEqual, "above\n\n---\n\nbelow")
+		})
+
+		Convey("Windows line endings are read", func() {
+			nb, err := notebook.Unmarshal("---\r\ntitle: Windows\r\n---\r\n\r\n# Card {#win}\r\n\r\nbody\r\n")
+			So(err, ShouldBeNil)
+			So(nb.Title, ShouldEqual, "Windows")
+			So(nb.CurrentID(), ShouldEqual, "win")
+		})
+
+		Convey("An empty notebook can be read", func() {
+			nb, err := notebook.Unmarshal("---\ntitle: Empty\n---\n")
+			So(err, ShouldBeNil)
+			So(nb.GetTree(), ShouldHaveLength, 0)
+			title, _ := nb.GetCard()
+			So(title, ShouldEqual, "")
+		})
+
+		Convey("Only some depths may be written", func() {
+			nb, err := notebook.Unmarshal("---\ntitle: Depths\n---\n\n# A {#a}\n\na\n\n## B {#b}\n\nb\n\n### C {#c}\n\nc\n\n# D {#d}\n\nd\n")
+			So(err, ShouldBeNil)
+			So(nb.MarshalDepths(0, 0), ShouldEqual, nb.MarshalBody())
+			So(nb.MarshalDepths(1, 1), ShouldEqual, "\n# A {#a}\n\na\n\n# D {#d}\n\nd\n")
+			So(nb.MarshalDepths(2, 3), ShouldEqual, "\n# B {#b}\n\nb\n\n## C {#c}\n\nc\n")
+		})
+
+		Convey("Marshalling is stable for any notebook", func() {
+			err := quick.Check(func(r randomNotebook) bool {
+				marshalled := r.nb.Marshal()
+				nb2, err := notebook.Unmarshal(marshalled)
+				if err != nil {
+					t.Logf("unable to unmarshal %q: %v", marshalled, err)
+					return false
+				}
+				if !reflect.DeepEqual(flatten(nb2.GetTree(), 0), flatten(r.nb.GetTree(), 0)) {
+					t.Logf("tree changed for %q", marshalled)
+					return false
+				}
+				if nb2.Marshal() != marshalled {
+					t.Logf("marshalling not stable for %q", marshalled)
+					return false
+				}
+				return true
+			}, &quick.Config{MaxCount: 500})
+			So(err, ShouldBeNil)
+		})
+	})
+}
@@ -0,0 +1,94 @@
+package notebook
+
+import (
+	"fmt"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// knownKeys are the front matter keys which the notebook manages itself.
+var knownKeys = []string{"title", "author", "description", "revisions", "created", "modified", "trash", "statuses"}
+
+func isKnownKey(key string) bool {
+	for _, known := range knownKeys {
+		if key == known {
+			return true
+		}
+	}
+	return false
+}
+
+// frontMatter builds the notebook's front matter. Keys read in from the file come first, in their original order, and
+// any of the notebook's own keys which were missing follow them. The trash is left out when it is empty, as are the
+// statuses unless the notebook has its own.
+func (nb *Notebook) frontMatter() yaml.MapSlice {
+	known := map[string]interface{}{
+		"title":       nb.Title,
+		"author":      nb.Author,
+		"description": nb.Description,
+		"revisions":   nb.Revisions,
+		"created":     nb.Created,
+		"modified":    nb.Modified,
+	}
+	if len(nb.trash) > 0 {
+		known["trash"] = nb.trashEntries()
+	}
+	if len(nb.statuses) > 0 {
+		known["statuses"] = nb.statuses
+	}
+	result := yaml.MapSlice{}
+	for _, item := range nb.meta {
+		key := fmt.Sprint(item.Key)
+		if !isKnownKey(key) {
+			result = append(result, item)
+		} else if value, ok := known[key]; ok {
+			result = append(result, yaml.MapItem{Key: key, Value: value})
+			delete(known, key)
+		}
+	}
+	for _, key := range knownKeys {
+		if value, ok := known[key]; ok {
+			result = append(result, yaml.MapItem{Key: key, Value: value})
+		}
+	}
+	return result
+}
+
+// Meta returns the value of a front matter key, including those used by other tools such as static site generators.
+func (nb *Notebook) Meta(key string) (interface{}, bool) {
+	for _, item := range nb.frontMatter() {
+		if fmt.Sprint(item.Key) == key {
+			return item.Value, true
+		}
+	}
+	return nil, false
+}
+
+// SetMeta sets the value of a front matter key that the notebook does not manage itself. Keys keep their position in
+// the front matter, and new keys are added after those read from the file. Setting a key to nil removes it.
+func (nb *Notebook) SetMeta(key string, value interface{}) error {
+	if isKnownKey(key) {
+		return fmt.Errorf("%s is managed by the notebook", key)
+	}
+	nb.record()
+
+	// The front matter is rebuilt rather than changed in place so that the undo journal can hold on to the old one.
+	meta := yaml.MapSlice{}
+	found := false
+	for _, item := range nb.meta {
+		if fmt.Sprint(item.Key) == key {
+			found = true
+			if value == nil {
+				continue
+			}
+			item = yaml.MapItem{Key: item.Key, Value: value}
+		}
+		meta = append(meta, item)
+	}
+	if !found && value != nil {
+		meta = append(meta, yaml.MapItem{Key: key, Value: value})
+	}
+	nb.meta = meta
+	nb.dirty = true
+	return nil
+}
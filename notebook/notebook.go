@@ -2,21 +2,30 @@ package notebook
 
 import (
 	"fmt"
+	"strings"
 	"time"
+	"unicode/utf8"
+
+	yaml "gopkg.in/yaml.v2"
 )
 
 type Notebook struct {
-	filename     string
-	Title        string
-	Author       string
-	Description  string
-	Revisions    []Revision
-	Created      time.Time
-	Modified     time.Time
-	root         *card
-	currentCard  *card
-	currentIndex []int
-	dirty        bool
+	filename    string
+	Title       string
+	Author      string
+	Description string
+	Revisions   []Revision
+	Created     time.Time
+	Modified    time.Time
+	root        *card
+	currentCard *card
+	lastID      int
+	meta        yaml.MapSlice
+	dirty       bool
+	backups     int
+	journal     journal
+	trash       []trashed
+	statuses    []string
 }
 
 type Revision struct {
@@ -25,8 +34,13 @@ type Revision struct {
 }
 
 type card struct {
+	id         string
 	title      string
 	body       string
+	target     int
+	status     string
+	tags       []string
+	properties Properties
 	parent     *card
 	next       *card
 	prev       *card
@@ -34,14 +48,39 @@ type card struct {
 }
 
 type Card struct {
+	ID       string
 	Title    string
 	Body     string
 	Current  bool
 	Children []Card
+
+	// Depth is how many levels deep the card is, with top-level cards at depth 1, and Position is where the card is
+	// among its siblings, starting from 1.
+	Depth    int
+	Position int
+
+	// Counts is the length of the card's body, and Total that of its body along with those of all of its children.
+	Counts Counts
+	Total  Counts
+
+	// Target is the number of words the card is meant to have, if one has been set. TotalTarget is the card's own
+	// target if it has one, or otherwise the sum of those of its children.
+	Target      int
+	TotalTarget int
+
+	// Status is the stage the card has reached, which is one of the notebook's statuses or empty.
+	Status string
+
+	// Tags label the card, such as with its point of view character, subplot or location.
+	Tags []string
+
+	// Properties hold structured information about the card, such as the date on which a scene takes place.
+	Properties Properties
 }
 
 // SetMetadata sets the metadata for the notebook.
 func (nb *Notebook) SetMetadata(title, author, description string) {
+	nb.record()
 	nb.Title = title
 	nb.Author = author
 	nb.Description = description
@@ -50,6 +89,7 @@ func (nb *Notebook) SetMetadata(title, author, description string) {
 
 // AddRevision adds a timestamped revision message to the notebook.
 func (nb *Notebook) AddRevision(text string) {
+	nb.record()
 	nb.Revisions = append([]Revision{Revision{
 		Message:   text,
 		Timestamp: time.Now(),
@@ -60,7 +100,9 @@ func (nb *Notebook) AddRevision(text string) {
 
 // AddCard adds a card to the notebook
 func (nb *Notebook) AddCard(title, body string, child bool) {
+	nb.record()
 	c := &card{
+		id:    nb.newID(),
 		title: title,
 		body:  body,
 	}
@@ -91,16 +133,11 @@ func (nb *Notebook) GetCard() (string, string) {
 	return nb.currentCard.title, nb.currentCard.body
 }
 
-func (c *card) getTree(nb *Notebook) []Card {
+func (c *card) getTree(nb *Notebook, depth int) []Card {
 	result := []Card{}
 	currChild := c.firstChild
 	for currChild != nil {
-		result = append(result, Card{
-			Title:    currChild.title,
-			Body:     currChild.body,
-			Current:  currChild == nb.currentCard,
-			Children: currChild.getTree(nb),
-		})
+		result = append(result, currChild.snapshot(nb, depth+1, len(result)+1))
 		currChild = currChild.next
 	}
 	return result
@@ -108,7 +145,7 @@ func (c *card) getTree(nb *Notebook) []Card {
 
 // GetTree returns a tree-representation of the contents of all cards
 func (nb *Notebook) GetTree() []Card {
-	return nb.root.getTree(nb)
+	return nb.root.getTree(nb, 0)
 }
 
 // EditCard changes the contents of the current card.
@@ -116,11 +153,57 @@ func (nb *Notebook) EditCard(title, body string) {
 	if nb.currentCard == nb.root {
 		return
 	}
+	if nb.currentCard.title == title && nb.currentCard.body == body {
+		return
+	}
+	nb.record()
 	nb.currentCard.title = title
 	nb.currentCard.body = body
 	nb.dirty = true
 }
 
+// CardEdit holds everything that may be changed about a card in the editor. Tags and Properties are left as they are
+// when nil.
+type CardEdit struct {
+	Title      string
+	Body       string
+	Tags       []string
+	Properties Properties
+}
+
+// EditCardDetails applies an edit to the current card as a single change, so that it is undone all at once.
+func (nb *Notebook) EditCardDetails(edit CardEdit) error {
+	if nb.currentCard == nb.root {
+		return nil
+	}
+	tags := nb.currentCard.tags
+	if edit.Tags != nil {
+		var err error
+		if tags, err = checkTags(edit.Tags); err != nil {
+			return err
+		}
+	}
+	props := nb.currentCard.properties
+	if edit.Properties != nil {
+		var err error
+		if props, err = checkProperties(edit.Properties); err != nil {
+			return err
+		}
+	}
+	if nb.currentCard.title == edit.Title && nb.currentCard.body == edit.Body &&
+		strings.Join(tags, " ") == strings.Join(nb.currentCard.tags, " ") &&
+		props.String() == nb.currentCard.properties.String() {
+		return nil
+	}
+	nb.record()
+	nb.currentCard.title = edit.Title
+	nb.currentCard.body = edit.Body
+	nb.currentCard.tags = tags
+	nb.currentCard.properties = props
+	nb.dirty = true
+	return nil
+}
+
 // Cycle set the current card by moving through the current card stack, looping around on overflow.
 func (nb *Notebook) Cycle(amount int) {
 	diff := 0
@@ -165,7 +248,7 @@ func (nb *Notebook) Exit() {
 	}
 }
 
-// Delete deletes a card. If it has child and force is not set, it refuses.
+// Delete deletes a card, moving it and its children to the trash. If it has child and force is not set, it refuses.
 func (nb *Notebook) Delete(force bool) error {
 	if nb.currentCard == nb.root {
 		return fmt.Errorf("nothing to delete")
@@ -173,18 +256,17 @@ func (nb *Notebook) Delete(force bool) error {
 	if nb.currentCard.firstChild != nil && !force {
 		return fmt.Errorf("card still has children, delete requires force")
 	}
-	if nb.currentCard.prev == nil {
-		nb.currentCard.parent.firstChild = nb.currentCard.next
-		if nb.currentCard.next == nil {
-			nb.currentCard = nb.currentCard.parent
-		} else {
-			nb.currentCard = nb.currentCard.next
-			nb.currentCard.prev = nil
-		}
-	} else {
-		nb.currentCard.prev.next = nb.currentCard.next
-		nb.currentCard = nb.currentCard.prev
+	nb.record()
+	deleted := nb.currentCard
+	switch {
+	case deleted.prev != nil:
+		nb.currentCard = deleted.prev
+	case deleted.next != nil:
+		nb.currentCard = deleted.next
+	default:
+		nb.currentCard = deleted.parent
 	}
+	nb.moveToTrash(deleted)
 	nb.dirty = true
 	return nil
 }
@@ -197,6 +279,10 @@ func (nb *Notebook) Move(amount int) {
 	} else {
 		diff = 1
 	}
+	if !nb.canShift(amount) {
+		return
+	}
+	nb.record()
 	for amount != 0 {
 		amount += diff
 		if diff == 1 {
@@ -245,6 +331,10 @@ func (nb *Notebook) Merge(amount int) {
 	} else {
 		diff = 1
 	}
+	if !nb.canShift(amount) {
+		return
+	}
+	nb.record()
 	for amount != 0 {
 		amount += diff
 		if diff == 1 {
@@ -341,16 +431,23 @@ func (nb *Notebook) Promote() error {
 	if nb.currentCard == nb.root || nb.currentCard.parent == nb.root {
 		return fmt.Errorf("unable to promote any further")
 	}
+	nb.record()
 	parent := nb.currentCard.parent
 	if nb.currentCard.prev != nil {
 		nb.currentCard.prev.next = nb.currentCard.next
 	}
+	if nb.currentCard.next != nil {
+		nb.currentCard.next.prev = nb.currentCard.prev
+	}
 	if nb.currentCard == parent.firstChild {
 		parent.firstChild = nb.currentCard.next
 	}
 	nb.currentCard.prev = parent
 	nb.currentCard.parent = parent.parent
 	nb.currentCard.next = parent.next
+	if parent.next != nil {
+		parent.next.prev = nb.currentCard
+	}
 	parent.next = nb.currentCard
 	nb.dirty = true
 	return nil
@@ -361,6 +458,7 @@ func (nb *Notebook) PromoteAll(replace bool) error {
 	if nb.currentCard == nb.root || nb.currentCard.parent == nb.root {
 		return fmt.Errorf("unable to promote any further")
 	}
+	nb.record()
 	parent := nb.currentCard.parent
 	first := parent.firstChild
 	first.prev = parent
@@ -369,6 +467,9 @@ func (nb *Notebook) PromoteAll(replace bool) error {
 		curr.parent = parent.parent
 		if curr.next == nil {
 			curr.next = parent.next
+			if curr.next != nil {
+				curr.next.prev = curr
+			}
 			break
 		}
 		curr = curr.next
@@ -380,12 +481,116 @@ func (nb *Notebook) PromoteAll(replace bool) error {
 		first.prev = parent.prev
 		if first.prev != nil {
 			first.prev.next = first
+		} else {
+			parent.parent.firstChild = first
+		}
+	}
+	nb.dirty = true
+	return nil
+}
+
+// Split cuts the body of the current card at the given byte offset, moving the rest of the body into a new card with
+// the given title which follows it. If moveChildren is true, the children of the current card are moved to the new
+// card. The new card becomes the current card.
+func (nb *Notebook) Split(offset int, newTitle string, moveChildren bool) error {
+	if nb.currentCard == nb.root {
+		return fmt.Errorf("nothing to split")
+	}
+	body := nb.currentCard.body
+	if offset < 0 || offset > len(body) || (offset < len(body) && !utf8.RuneStart(body[offset])) {
+		return fmt.Errorf("split offset out of range")
+	}
+	nb.record()
+	before, after := body[:offset], body[offset:]
+	if offset > 0 && body[offset-1] != '\n' {
+		after = strings.TrimLeft(after, " \t")
+	}
+	c := &card{
+		id:     nb.newID(),
+		title:  newTitle,
+		body:   strings.TrimLeft(after, "\n"),
+		parent: nb.currentCard.parent,
+		prev:   nb.currentCard,
+		next:   nb.currentCard.next,
+	}
+	if c.next != nil {
+		c.next.prev = c
+	}
+	nb.currentCard.next = c
+	nb.currentCard.body = strings.TrimRight(before, " \t\n")
+	if moveChildren {
+		c.firstChild = nb.currentCard.firstChild
+		nb.currentCard.firstChild = nil
+		for child := c.firstChild; child != nil; child = child.next {
+			child.parent = c
 		}
 	}
+	nb.currentCard = c
+	nb.dirty = true
+	return nil
+}
+
+// Demote makes the current card the last child of the card before it, bringing its own children along with it.
+func (nb *Notebook) Demote() error {
+	if nb.currentCard == nb.root || nb.currentCard.prev == nil {
+		return fmt.Errorf("unable to demote without a previous card")
+	}
+	nb.record()
+	nb.demote(nb.currentCard, nb.currentCard)
+	nb.dirty = true
+	return nil
+}
+
+// DemoteAll makes the current card and all of the cards following it the last children of the card before it.
+func (nb *Notebook) DemoteAll() error {
+	if nb.currentCard == nb.root || nb.currentCard.prev == nil {
+		return fmt.Errorf("unable to demote without a previous card")
+	}
+	nb.record()
+	last := nb.currentCard
+	for last.next != nil {
+		last = last.next
+	}
+	nb.demote(nb.currentCard, last)
 	nb.dirty = true
 	return nil
 }
 
+// demote moves the run of siblings from first to last to the end of the children of the card before first.
+func (nb *Notebook) demote(first, last *card) {
+	parent := first.prev
+	parent.next = last.next
+	if parent.next != nil {
+		parent.next.prev = parent
+	}
+	last.next = nil
+	for curr := first; curr != nil; curr = curr.next {
+		curr.parent = parent
+	}
+	if parent.firstChild == nil {
+		parent.firstChild = first
+		first.prev = nil
+		return
+	}
+	child := parent.firstChild
+	for child.next != nil {
+		child = child.next
+	}
+	child.next = first
+	first.prev = child
+}
+
+// canShift returns whether the current card has a sibling in the direction of amount to move or merge with.
+func (nb *Notebook) canShift(amount int) bool {
+	if nb.currentCard == nb.root {
+		return false
+	}
+	if amount > 0 {
+		return nb.currentCard.next != nil
+	}
+	return amount < 0 && nb.currentCard.prev != nil
+}
+
 // Dirty returns whether or not the notebook has changes that have not been saved.
 func (nb *Notebook) Dirty() bool {
 	return nb.dirty
@@ -404,5 +609,6 @@ func New(filename, title, author, description string) *Notebook {
 		Revisions:   []Revision{},
 		root:        root,
 		currentCard: root,
+		journal:     journal{depth: DefaultUndoDepth},
 	}
 }
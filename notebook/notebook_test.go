@@ -50,6 +50,48 @@ func TestNotebook(t *testing.T) {
 				So(nb2.Revisions[0].Timestamp.After(nb.Created), ShouldBeTrue)
 				So(nb2.Modified.After(nb.Created), ShouldBeTrue)
 			})
+
+			Convey("Other front matter is kept in its original order", func() {
+				nb2, err := notebook.Unmarshal("---\nlayout: post\ntitle: Kept\ntags:\n- one\n- two\nparams:\n  z: 1\n  a: 2\n---\n\n# Card\n")
+				So(err, ShouldBeNil)
+				out, err := nb2.MarshalHeader()
+				So(err, ShouldBeNil)
+				So(string(out), ShouldStartWith, "layout: post\ntitle: Kept\ntags:\n- one\n- two\nparams:\n  z: 1\n  a: 2\nauthor: \"\"\n")
+
+				value, ok := nb2.Meta("layout")
+				So(ok, ShouldBeTrue)
+				So(value, ShouldEqual, "post")
+				value, ok = nb2.Meta("title")
+				So(ok, ShouldBeTrue)
+				So(value, ShouldEqual, "Kept")
+				_, ok = nb2.Meta("permalink")
+				So(ok, ShouldBeFalse)
+
+				Convey("And can be changed", func() {
+					So(nb2.SetMeta("layout", "page"), ShouldBeNil)
+					So(nb2.SetMeta("tags", nil), ShouldBeNil)
+					So(nb2.SetMeta("draft", true), ShouldBeNil)
+					So(nb2.Dirty(), ShouldBeTrue)
+					out, err = nb2.MarshalHeader()
+					So(err, ShouldBeNil)
+					So(string(out), ShouldStartWith, "layout: page\ntitle: Kept\nparams:\n  z: 1\n  a: 2\n")
+					So(string(out), ShouldContainSubstring, "  a: 2\ndraft: true\n")
+
+					nb3, err := notebook.Unmarshal(nb2.Marshal())
+					So(err, ShouldBeNil)
+					value, _ = nb3.Meta("draft")
+					So(value, ShouldEqual, true)
+
+					So(nb2.Undo(), ShouldBeNil)
+					_, ok = nb2.Meta("draft")
+					So(ok, ShouldBeFalse)
+				})
+
+				Convey("But not the notebook's own keys", func() {
+					err := nb2.SetMeta("title", "Changed")
+					So(err.Error(), ShouldEqual, "title is managed by the notebook")
+				})
+			})
 		})
 
 		Convey("It can have cards", func() {
@@ -230,14 +272,14 @@ func TestNotebook(t *testing.T) {
 				So(err, ShouldBeNil)
 
 				body = nb.MarshalBody()
-				So(body, ShouldEqual, "\n# Card 1 Title\n\nCard 1 body\n")
+				So(body, ShouldEqual, "\n# Card 1 Title {#c1}\n\nCard 1 body\n")
 
 				nb.AddCard("bad", "wolf", true)
 				err = nb.Delete(false)
 				So(err, ShouldBeNil)
 
 				body = nb.MarshalBody()
-				So(body, ShouldEqual, "\n# Card 1 Title\n\nCard 1 body\n")
+				So(body, ShouldEqual, "\n# Card 1 Title {#c1}\n\nCard 1 body\n")
 
 				nb.AddCard("bad", "wolf", true)
 				nb.AddCard("good", "wolf", false)
@@ -246,7 +288,7 @@ func TestNotebook(t *testing.T) {
 				So(err, ShouldBeNil)
 
 				body = nb.MarshalBody()
-				So(body, ShouldEqual, "\n# Card 1 Title\n\nCard 1 body\n\n## good\n\nwolf\n")
+				So(body, ShouldEqual, "\n# Card 1 Title {#c1}\n\nCard 1 body\n\n## good {#c5}\n\nwolf\n")
 
 				nb.Exit()
 				err = nb.Delete(false)
@@ -261,7 +303,7 @@ func TestNotebook(t *testing.T) {
 				nb.AddCard("Card 2.1 Title", "Card 2.1 body", true)
 
 				body = nb.MarshalBody()
-				So(body, ShouldEqual, "\n# Card 1 Title\n\nCard 1 body\n\n# Card 2 Title\n\nCard 2 body\n\n## Card 2.1 Title\n\nCard 2.1 body\n")
+				So(body, ShouldEqual, "\n# Card 1 Title {#c1}\n\nCard 1 body\n\n# Card 2 Title {#c2}\n\nCard 2 body\n\n## Card 2.1 Title {#c3}\n\nCard 2.1 body\n")
 
 				nb.Exit()
 				nb.AddCard("Card 2.1-a Title", "Card 2.1-a body", true)
@@ -269,7 +311,7 @@ func TestNotebook(t *testing.T) {
 				nb.AddCard("Card 2.1-b Title", "Card 2.1-b body", true)
 
 				body = nb.MarshalBody()
-				So(body, ShouldEqual, "\n# Card 1 Title\n\nCard 1 body\n\n# Card 2 Title\n\nCard 2 body\n\n## Card 2.1 Title\n\nCard 2.1 body\n\n## Card 2.1-a Title\n\nCard 2.1-a body\n\n## Card 2.1-b Title\n\nCard 2.1-b body\n")
+				So(body, ShouldEqual, "\n# Card 1 Title {#c1}\n\nCard 1 body\n\n# Card 2 Title {#c2}\n\nCard 2 body\n\n## Card 2.1 Title {#c3}\n\nCard 2.1 body\n\n## Card 2.1-a Title {#c4}\n\nCard 2.1-a body\n\n## Card 2.1-b Title {#c5}\n\nCard 2.1-b body\n")
 				nb.Delete(true)
 				nb.Delete(true)
 
@@ -405,6 +447,128 @@ func TestNotebook(t *testing.T) {
 					})
 				})
 			})
+
+			Convey("Cards can be demoted", func() {
+
+				nb = notebook.New("", "Demoting", "", "")
+				nb.AddCard("Act 1", "1", false)
+				nb.AddCard("Act 2", "2", false)
+				nb.AddCard("Scene 2.1", "2.1", true)
+				nb.Exit()
+				nb.AddCard("Act 3", "3", false)
+				nb.Cycle(-1)
+
+				Convey("Under the previous card, keeping their children", func() {
+					err := nb.Demote()
+					So(err, ShouldBeNil)
+					title, _ = nb.GetCard()
+					So(title, ShouldEqual, "Act 2")
+					tree := nb.GetTree()
+					So(tree, ShouldHaveLength, 2)
+					So(tree[0].Children, ShouldHaveLength, 1)
+					So(tree[0].Children[0].Title, ShouldEqual, "Act 2")
+					So(tree[0].Children[0].Children[0].Title, ShouldEqual, "Scene 2.1")
+					So(tree[1].Title, ShouldEqual, "Act 3")
+
+					Convey("After any existing children", func() {
+						nb.Exit()
+						nb.Cycle(1)
+						err = nb.Demote()
+						So(err, ShouldBeNil)
+						tree = nb.GetTree()
+						So(tree, ShouldHaveLength, 1)
+						So(tree[0].Children[1].Title, ShouldEqual, "Act 3")
+						So(tree[0].Children[1].Current, ShouldBeTrue)
+					})
+
+					Convey("And promoted back again", func() {
+						err = nb.Promote()
+						So(err, ShouldBeNil)
+						So(nb.MarshalBody(), ShouldEqual, "\n# Act 1 {#c1}\n\n1\n\n# Act 2 {#c2}\n\n2\n\n## Scene 2.1 {#c3}\n\n2.1\n\n# Act 3 {#c4}\n\n3\n")
+						nb.Cycle(1)
+						title, _ = nb.GetCard()
+						So(title, ShouldEqual, "Act 3")
+						nb.Cycle(-2)
+						title, _ = nb.GetCard()
+						So(title, ShouldEqual, "Act 1")
+					})
+				})
+
+				Convey("Along with all of the following cards", func() {
+					err := nb.DemoteAll()
+					So(err, ShouldBeNil)
+					So(nb.MarshalBody(), ShouldEqual, "\n# Act 1 {#c1}\n\n1\n\n## Act 2 {#c2}\n\n2\n\n### Scene 2.1 {#c3}\n\n2.1\n\n## Act 3 {#c4}\n\n3\n")
+					nb.Cycle(1)
+					title, _ = nb.GetCard()
+					So(title, ShouldEqual, "Act 3")
+				})
+
+				Convey("But not without a previous card", func() {
+					nb.Cycle(-1)
+					err := nb.Demote()
+					So(err.Error(), ShouldEqual, "unable to demote without a previous card")
+					err = nb.DemoteAll()
+					So(err.Error(), ShouldEqual, "unable to demote without a previous card")
+
+					nb2 := notebook.New("empty.md", "Empty", "Empty", "Empty")
+					err = nb2.Demote()
+					So(err.Error(), ShouldEqual, "unable to demote without a previous card")
+				})
+			})
+
+			Convey("Cards can be split", func() {
+
+				nb = notebook.New("", "Splitting", "", "")
+				nb.AddCard("Chapter", "First scene.\n\nSecond scene.", false)
+				nb.AddCard("Child", "child", true)
+				nb.Exit()
+				nb.AddCard("After", "after", false)
+				nb.Cycle(-1)
+
+				Convey("Into a following card", func() {
+					err := nb.Split(14, "Second", false)
+					So(err, ShouldBeNil)
+					So(nb.MarshalBody(), ShouldEqual, "\n# Chapter {#c1}\n\nFirst scene.\n\n## Child {#c2}\n\nchild\n\n# Second {#c4}\n\nSecond scene.\n\n# After {#c3}\n\nafter\n")
+					title, body = nb.GetCard()
+					So(title, ShouldEqual, "Second")
+					nb.Cycle(1)
+					title, _ = nb.GetCard()
+					So(title, ShouldEqual, "After")
+					nb.Cycle(-2)
+					title, _ = nb.GetCard()
+					So(title, ShouldEqual, "Chapter")
+				})
+
+				Convey("Moving the children to the new card", func() {
+					err := nb.Split(5, "Second", true)
+					So(err, ShouldBeNil)
+					So(nb.MarshalBody(), ShouldEqual, "\n# Chapter {#c1}\n\nFirst\n\n# Second {#c4}\n\nscene.\n\nSecond scene.\n\n## Child {#c2}\n\nchild\n\n# After {#c3}\n\nafter\n")
+				})
+
+				Convey("At either end of the body", func() {
+					err := nb.Split(0, "Second", false)
+					So(err, ShouldBeNil)
+					_, body = nb.GetCard()
+					So(body, ShouldEqual, "First scene.\n\nSecond scene.")
+					nb.Cycle(-1)
+					_, body = nb.GetCard()
+					So(body, ShouldEqual, "")
+				})
+
+				Convey("But not out of range", func() {
+					err := nb.Split(-1, "Second", false)
+					So(err.Error(), ShouldEqual, "split offset out of range")
+					err = nb.Split(100, "Second", false)
+					So(err.Error(), ShouldEqual, "split offset out of range")
+					nb.EditCard("Chapter", "é")
+					err = nb.Split(1, "Second", false)
+					So(err.Error(), ShouldEqual, "split offset out of range")
+
+					nb2 := notebook.New("empty.md", "Empty", "Empty", "Empty")
+					err = nb2.Split(0, "Second", false)
+					So(err.Error(), ShouldEqual, "nothing to split")
+				})
+			})
 		})
 
 		Convey("It can be marshalled and unmarshalled", func() {
@@ -425,11 +589,13 @@ func TestNotebook(t *testing.T) {
 			_, err = notebook.Unmarshal("bad-wolf")
 			So(err.Error(), ShouldEqual, "malformed notebook; must contain metadata block and body")
 			_, err = notebook.Unmarshal("---\nbad---\nwolf")
+			So(err.Error(), ShouldEqual, "malformed notebook; must contain metadata block and body")
+			_, err = notebook.Unmarshal("---\nbad\n---\nwolf")
 			So(err.Error(), ShouldContainSubstring, "yaml: unmarshal errors")
 			_, err = notebook.Unmarshal("---\n---\n\nbad-wolf")
 			So(err.Error(), ShouldEqual, "malformed notebook; cannot have body without header")
 			_, err = notebook.Unmarshal("---\n---\n\n#bad-wolf")
-			So(err.Error(), ShouldEqual, "malformed notebook; title must contain depth marker and text")
+			So(err.Error(), ShouldEqual, "malformed notebook; cannot have body without header")
 			_, err = notebook.Unmarshal("---\n---\n\n## bad-wolf")
 			So(err.Error(), ShouldEqual, "malformed notebook; must start at header depth 1, found ## bad-wolf")
 			_, err = notebook.Unmarshal("---\n---\n\n# bad\n\n### wolf")
@@ -0,0 +1,212 @@
+package notebook
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// propertiesFence opens the block of properties at the start of a card's body. It is a fenced code block so that the
+// notebook stays valid Markdown.
+const propertiesFence = "```properties"
+
+// Property is a piece of structured information about a card, such as `pov: Ada`.
+type Property struct {
+	Key   string
+	Value string
+}
+
+// Properties are the properties of a card, in order.
+type Properties []Property
+
+// validKey returns whether a property key may be used. Keys start with a letter and hold only letters, numbers,
+// spaces, dashes, dots and underscores, so that they never need quoting.
+func validKey(key string) bool {
+	if key == "" || strings.TrimSpace(key) != key {
+		return false
+	}
+	for i, r := range key {
+		if unicode.IsLetter(r) {
+			continue
+		}
+		if i == 0 || !(unicode.IsDigit(r) || strings.ContainsRune(" -._", r)) {
+			return false
+		}
+	}
+	return true
+}
+
+// Get returns the value of a property.
+func (props Properties) Get(key string) (string, bool) {
+	for _, prop := range props {
+		if prop.Key == key {
+			return prop.Value, true
+		}
+	}
+	return "", false
+}
+
+// with returns a copy of the properties with one set, keeping its position if it is already there. An empty value
+// removes the property.
+func (props Properties) with(key, value string) Properties {
+	result := Properties{}
+	found := false
+	for _, prop := range props {
+		if prop.Key == key {
+			found = true
+			if value == "" {
+				continue
+			}
+			prop.Value = value
+		}
+		result = append(result, prop)
+	}
+	if !found && value != "" {
+		result = append(result, Property{Key: key, Value: value})
+	}
+	return result
+}
+
+// String returns the properties as a YAML mapping, which may be read back with ParseProperties.
+func (props Properties) String() string {
+	if len(props) == 0 {
+		return ""
+	}
+	slice := yaml.MapSlice{}
+	for _, prop := range props {
+		slice = append(slice, yaml.MapItem{Key: prop.Key, Value: prop.Value})
+	}
+	out, _ := yaml.Marshal(slice)
+	return string(out)
+}
+
+// ParseProperties reads properties from a YAML mapping of keys to single values.
+func ParseProperties(text string) (Properties, error) {
+	// Keys are read in order from a MapSlice, but values are read as strings from a map, so that YAML doesn't turn
+	// values such as `yes` into something else.
+	ordered := yaml.MapSlice{}
+	if err := yaml.Unmarshal([]byte(text), &ordered); err != nil {
+		return nil, fmt.Errorf("invalid properties: %v", err)
+	}
+	values := map[string]string{}
+	if err := yaml.Unmarshal([]byte(text), &values); err != nil {
+		return nil, fmt.Errorf("invalid properties: %v", err)
+	}
+	props := Properties{}
+	for _, item := range ordered {
+		key := fmt.Sprint(item.Key)
+		if !validKey(key) {
+			return nil, fmt.Errorf("invalid property key %q", key)
+		}
+		value, ok := values[key]
+		if !ok {
+			value = fmt.Sprint(item.Value)
+		}
+		if strings.ContainsAny(value, "\r\n") {
+			return nil, fmt.Errorf("property %s must be a single line", key)
+		}
+		props = props.with(key, value)
+	}
+	return props, nil
+}
+
+// splitProperties separates the block of properties at the start of a card's body from the rest of it. If there is no
+// such block, or it can't be read, the body is left alone.
+func splitProperties(lines []string) (Properties, []string, bool) {
+	start := 0
+	for start < len(lines) && strings.TrimSpace(lines[start]) == "" {
+		start++
+	}
+	if start == len(lines) || strings.TrimRight(lines[start], " ") != propertiesFence {
+		return nil, lines, false
+	}
+	for end := start + 1; end < len(lines); end++ {
+		if strings.TrimRight(lines[end], " ") == "```" {
+			props, err := ParseProperties(strings.Join(lines[start+1:end], "\n"))
+			if err != nil {
+				return nil, lines, false
+			}
+			return props, lines[end+1:], true
+		}
+	}
+	return nil, lines, false
+}
+
+// marshalProperties generates the block of properties at the start of the card's body. A card without properties
+// whose body starts with something that looks like them is given an empty block so that its body is read back as is.
+func (c *card) marshalProperties() string {
+	if len(c.properties) == 0 {
+		if _, _, ok := splitProperties(strings.Split(c.body, "\n")); !ok {
+			return ""
+		}
+	}
+	return fmt.Sprintf("%s\n%s```\n\n", propertiesFence, c.properties)
+}
+
+// SetProperty sets a property of the current card. An empty value removes it.
+func (nb *Notebook) SetProperty(key, value string) error {
+	if nb.currentCard == nb.root {
+		return fmt.Errorf("no card to set a property on")
+	}
+	if !validKey(key) {
+		return fmt.Errorf("invalid property key %q", key)
+	}
+	if strings.ContainsAny(value, "\r\n") {
+		return fmt.Errorf("property %s must be a single line", key)
+	}
+	if current, _ := nb.currentCard.properties.Get(key); current == value {
+		return nil
+	}
+	nb.record()
+	nb.currentCard.properties = nb.currentCard.properties.with(key, value)
+	nb.dirty = true
+	return nil
+}
+
+// checkProperties returns the properties with repeated keys and empty values removed, or an error if any of them is
+// invalid.
+func checkProperties(props Properties) (Properties, error) {
+	result := Properties{}
+	for _, prop := range props {
+		if !validKey(prop.Key) {
+			return nil, fmt.Errorf("invalid property key %q", prop.Key)
+		}
+		if strings.ContainsAny(prop.Value, "\r\n") {
+			return nil, fmt.Errorf("property %s must be a single line", prop.Key)
+		}
+		result = result.with(prop.Key, prop.Value)
+	}
+	return result, nil
+}
+
+// SetProperties replaces all of the properties of the current card.
+func (nb *Notebook) SetProperties(props Properties) error {
+	if nb.currentCard == nb.root {
+		return fmt.Errorf("no card to set a property on")
+	}
+	result, err := checkProperties(props)
+	if err != nil {
+		return err
+	}
+	if result.String() == nb.currentCard.properties.String() {
+		return nil
+	}
+	nb.record()
+	nb.currentCard.properties = result
+	nb.dirty = true
+	return nil
+}
+
+// CardsWithProperty returns the IDs of the cards with the given property, in the order they appear in the notebook. If
+// value is empty, cards with any value for the property are included.
+func (nb *Notebook) CardsWithProperty(key, value string) []string {
+	ids := []string{}
+	nb.root.walk(func(c *card) {
+		if v, ok := c.properties.Get(key); ok && (value == "" || v == value) {
+			ids = append(ids, c.id)
+		}
+	})
+	return ids
+}
@@ -0,0 +1,135 @@
+package notebook_test
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/makyo/mandelnote/notebook"
+)
+
+func TestProperties(t *testing.T) {
+	Convey("When setting properties on cards", t, func() {
+
+		nb := notebook.New("", "Properties", "", "")
+		nb.AddCard("Act 1", "one", false)
+		So(nb.SetProperty("pov", "Ada"), ShouldBeNil)
+		So(nb.SetProperty("date", "1893-04-02"), ShouldBeNil)
+		nb.AddCard("Scene 1", "1.1", true)
+		So(nb.SetProperty("pov", "Bob"), ShouldBeNil)
+		nb.AddCard("Scene 2", "", false)
+		So(nb.SetProperty("pov", "Ada"), ShouldBeNil)
+		nb.Exit()
+
+		Convey("They are kept at the start of the body", func() {
+			So(nb.MarshalBody(), ShouldContainSubstring,
+				"# Act 1 {#c1}\n\n```properties\npov: Ada\ndate: \"1893-04-02\"\n```\n\none\n")
+			nb2, err := notebook.Unmarshal(nb.Marshal())
+			So(err, ShouldBeNil)
+			So(nb2.GetTree(), ShouldResemble, nb.GetTree())
+			So(nb2.GetTree()[0].Properties, ShouldResemble, notebook.Properties{
+				{Key: "pov", Value: "Ada"},
+				{Key: "date", Value: "1893-04-02"},
+			})
+			So(nb2.GetTree()[0].Body, ShouldEqual, "one")
+			So(nb2.GetTree()[0].Children[1].Body, ShouldEqual, "")
+		})
+
+		Convey("Values are read as written", func() {
+			props, err := notebook.ParseProperties("done: yes\ncount: 3\nnote: 'a: b'\n")
+			So(err, ShouldBeNil)
+			So(props, ShouldResemble, notebook.Properties{
+				{Key: "done", Value: "yes"},
+				{Key: "count", Value: "3"},
+				{Key: "note", Value: "a: b"},
+			})
+			value, ok := props.Get("note")
+			So(ok, ShouldBeTrue)
+			So(value, ShouldEqual, "a: b")
+			props2, err := notebook.ParseProperties(props.String())
+			So(err, ShouldBeNil)
+			So(props2, ShouldResemble, props)
+		})
+
+		Convey("Bodies which look like properties are left alone", func() {
+			nb.Select("c3")
+			nb.EditCard("Scene 2", "```properties\npov: Bob\n```\n\ntext")
+			nb2, err := notebook.Unmarshal(nb.Marshal())
+			So(err, ShouldBeNil)
+			So(nb2.GetTree()[0].Children[1].Body, ShouldEqual, "```properties\npov: Bob\n```\n\ntext")
+			So(nb2.GetTree()[0].Children[1].Properties, ShouldResemble, notebook.Properties{{Key: "pov", Value: "Ada"}})
+
+			nb.Select("c2")
+			nb.EditCard("Scene 1", "```properties\n- not a mapping\n```")
+			nb3, err := notebook.Unmarshal(nb.Marshal())
+			So(err, ShouldBeNil)
+			So(nb3.GetTree()[0].Children[0].Body, ShouldEqual, "```properties\n- not a mapping\n```")
+			So(nb3.GetTree()[0].Children[0].Properties, ShouldResemble, notebook.Properties{{Key: "pov", Value: "Bob"}})
+		})
+
+		Convey("Cards can be found by property", func() {
+			So(nb.CardsWithProperty("pov", "Ada"), ShouldResemble, []string{"c1", "c3"})
+			So(nb.CardsWithProperty("pov", ""), ShouldResemble, []string{"c1", "c2", "c3"})
+			So(nb.CardsWithProperty("date", "1893"), ShouldBeEmpty)
+		})
+
+		Convey("Properties can be changed, removed and the change undone", func() {
+			So(nb.SetProperty("pov", "Cy"), ShouldBeNil)
+			So(nb.GetTree()[0].Properties[0], ShouldResemble, notebook.Property{Key: "pov", Value: "Cy"})
+			So(nb.SetProperty("pov", ""), ShouldBeNil)
+			So(nb.GetTree()[0].Properties, ShouldResemble, notebook.Properties{{Key: "date", Value: "1893-04-02"}})
+			So(nb.SetProperties(nil), ShouldBeNil)
+			So(nb.MarshalBody(), ShouldContainSubstring, "# Act 1 {#c1}\n\none\n")
+			So(nb.Undo(), ShouldBeNil)
+			So(nb.Undo(), ShouldBeNil)
+			So(nb.Undo(), ShouldBeNil)
+			So(nb.GetTree()[0].Properties[0].Value, ShouldEqual, "Ada")
+		})
+
+		Convey("Editing a card changes its properties and tags along with it, undone all at once", func() {
+			So(nb.SetTags([]string{"ada"}), ShouldBeNil)
+			So(nb.EditCardDetails(notebook.CardEdit{
+				Title:      "Act One",
+				Body:       "uno",
+				Tags:       []string{"bob", "bob"},
+				Properties: notebook.Properties{{Key: "pov", Value: "Bob"}},
+			}), ShouldBeNil)
+			c := nb.GetTree()[0]
+			So(c.Title, ShouldEqual, "Act One")
+			So(c.Body, ShouldEqual, "uno")
+			So(c.Tags, ShouldResemble, []string{"bob"})
+			So(c.Properties, ShouldResemble, notebook.Properties{{Key: "pov", Value: "Bob"}})
+
+			So(nb.Undo(), ShouldBeNil)
+			c = nb.GetTree()[0]
+			So(c.Title, ShouldEqual, "Act 1")
+			So(c.Tags, ShouldResemble, []string{"ada"})
+			So(c.Properties[0].Value, ShouldEqual, "Ada")
+
+			Convey("Leaving tags and properties alone when they aren't given", func() {
+				So(nb.EditCardDetails(notebook.CardEdit{Title: "Act 1", Body: "uno"}), ShouldBeNil)
+				c := nb.GetTree()[0]
+				So(c.Tags, ShouldResemble, []string{"ada"})
+				So(c.Properties, ShouldHaveLength, 2)
+			})
+
+			Convey("And changing nothing when any of them is invalid", func() {
+				So(nb.EditCardDetails(notebook.CardEdit{Title: "x", Tags: []string{"{x}"}}).Error(), ShouldEqual,
+					`invalid tag "{x}"`)
+				So(nb.GetTree()[0].Title, ShouldEqual, "Act 1")
+				tags, err := notebook.ParseTags(" bob\tpov=ada ")
+				So(tags, ShouldBeNil)
+				So(err.Error(), ShouldEqual, `invalid tag "pov=ada"`)
+			})
+		})
+
+		Convey("Bad properties are errors", func() {
+			So(nb.SetProperty("1st", "x").Error(), ShouldEqual, `invalid property key "1st"`)
+			So(nb.SetProperty("pov", "a\nb").Error(), ShouldEqual, "property pov must be a single line")
+			_, err := notebook.ParseProperties("pov: [a, b]")
+			So(err, ShouldNotBeNil)
+			So(notebook.New("", "Empty", "", "").SetProperty("pov", "Ada").Error(), ShouldEqual,
+				"no card to set a property on")
+		})
+	})
+}
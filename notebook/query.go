@@ -0,0 +1,253 @@
+package notebook
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Query selects cards by what they hold and where they are in the notebook. It is made up of terms separated by
+// spaces, all of which must match. A term preceded by `!` must not match. The terms are:
+//
+//	depth:N       the card is N levels deep, with top-level cards at depth 1
+//	position:N    the card is the Nth among its siblings
+//	children:N    the card has N children
+//	haschildren:  yes or no
+//	title:T       the title contains T
+//	body:T        the body contains T
+//	tag:T         the card is tagged T
+//	status:S      the card has the status S
+//	prop.key:V    the card has the property key with the value V, or any value if V is empty
+//	T             the title or body contains T
+//
+// Numbers may be compared, as in `depth:<3`, `children:>=2` or `position:2-4`. Text may be a single word or a
+// "quoted phrase", matched regardless of case, or a /regular expression/. Property keys with spaces in them are quoted,
+// as in `prop."point of view":Ada`.
+type Query []queryTerm
+
+// queryTerm is a single term in a query.
+type queryTerm struct {
+	negate bool
+	match  func(Card) bool
+}
+
+// ParseQuery reads a query.
+func ParseQuery(query string) (Query, error) {
+	result := Query{}
+	rest := strings.TrimSpace(query)
+	for rest != "" {
+		term := queryTerm{}
+		if rest[0] == '!' {
+			term.negate = true
+			rest = rest[1:]
+		}
+		field := ""
+		if strings.HasPrefix(rest, `prop."`) {
+			key, _, next, err := readQueryValue(rest[len("prop."):])
+			if err != nil {
+				return nil, err
+			}
+			if !strings.HasPrefix(next, ":") {
+				return nil, fmt.Errorf("expected : after property %q in query", key)
+			}
+			field, rest = "prop."+key, next[1:]
+		} else if end := strings.IndexFunc(rest, func(r rune) bool { return !validKeyRune(r) }); end > 0 && rest[end] == ':' {
+			field, rest = rest[:end], rest[end+1:]
+		}
+		value, quoted, next, err := readQueryValue(rest)
+		if err != nil {
+			return nil, err
+		}
+		rest = strings.TrimLeftFunc(next, unicode.IsSpace)
+		if term.match, err = queryMatcher(field, value, quoted); err != nil {
+			return nil, err
+		}
+		result = append(result, term)
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("query must not be empty")
+	}
+	return result, nil
+}
+
+// validKeyRune returns whether the rune may be part of a query field, which includes `prop.` followed by any property
+// key without spaces.
+func validKeyRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("-._", r)
+}
+
+// readQueryValue reads the value of a term from the start of the text, returning it along with how it was quoted (`"`,
+// `/` or nothing) and the rest of the text.
+func readQueryValue(text string) (string, byte, string, error) {
+	if text == "" || unicode.IsSpace(rune(text[0])) {
+		return "", 0, text, nil
+	}
+	quote := text[0]
+	if quote != '"' && quote != '/' {
+		end := strings.IndexFunc(text, unicode.IsSpace)
+		if end == -1 {
+			end = len(text)
+		}
+		return text[:end], 0, text[end:], nil
+	}
+	value := strings.Builder{}
+	for i := 1; i < len(text); i++ {
+		switch {
+		case text[i] == quote:
+			return value.String(), quote, text[i+1:], nil
+		case text[i] == '\\' && i+1 < len(text) && text[i+1] == quote:
+			value.WriteByte(quote)
+			i++
+		default:
+			value.WriteByte(text[i])
+		}
+	}
+	return "", 0, "", fmt.Errorf("unterminated %c in query", quote)
+}
+
+// queryMatcher builds the function which checks a card against a single term.
+func queryMatcher(field, value string, quoted byte) (func(Card) bool, error) {
+	switch field {
+	case "depth":
+		return numberMatcher(field, value, func(c Card) int { return c.Depth })
+	case "position":
+		return numberMatcher(field, value, func(c Card) int { return c.Position })
+	case "children":
+		return numberMatcher(field, value, func(c Card) int { return len(c.Children) })
+	case "haschildren":
+		switch strings.ToLower(value) {
+		case "yes", "true":
+			return func(c Card) bool { return len(c.Children) > 0 }, nil
+		case "no", "false":
+			return func(c Card) bool { return len(c.Children) == 0 }, nil
+		}
+		return nil, fmt.Errorf("haschildren must be yes or no, not %q", value)
+	case "title", "body", "":
+		re, err := textPattern(field, value, quoted)
+		if err != nil {
+			return nil, err
+		}
+		switch field {
+		case "title":
+			return func(c Card) bool { return re.MatchString(c.Title) }, nil
+		case "body":
+			return func(c Card) bool { return re.MatchString(c.Body) }, nil
+		}
+		return func(c Card) bool { return re.MatchString(c.Title) || re.MatchString(c.Body) }, nil
+	case "tag":
+		return func(c Card) bool { return hasTag(c.Tags, value) }, nil
+	case "status":
+		return func(c Card) bool { return c.Status == value }, nil
+	}
+	if !strings.HasPrefix(field, "prop.") {
+		return nil, fmt.Errorf("unknown field %s in query; properties are queried as prop.%s", field, field)
+	}
+	field = strings.TrimPrefix(field, "prop.")
+	if !validKey(field) {
+		return nil, fmt.Errorf("invalid property key %q", field)
+	}
+	if value == "" {
+		return func(c Card) bool {
+			_, ok := c.Properties.Get(field)
+			return ok
+		}, nil
+	}
+	if quoted == '/' {
+		re, err := textPattern(field, value, quoted)
+		if err != nil {
+			return nil, err
+		}
+		return func(c Card) bool {
+			v, ok := c.Properties.Get(field)
+			return ok && re.MatchString(v)
+		}, nil
+	}
+	return func(c Card) bool {
+		v, ok := c.Properties.Get(field)
+		return ok && strings.EqualFold(v, value)
+	}, nil
+}
+
+// textPattern compiles the text of a term, treating it as a regular expression if it was written between slashes.
+func textPattern(field, value string, quoted byte) (*regexp.Regexp, error) {
+	opts := SearchOptions{Mode: CaseInsensitive}
+	if quoted == '/' {
+		opts.Mode = Regexp
+	}
+	re, err := opts.compile(value)
+	if err != nil {
+		if field == "" {
+			return nil, fmt.Errorf("invalid search in query: %v", err)
+		}
+		return nil, fmt.Errorf("invalid search for %s: %v", field, err)
+	}
+	return re, nil
+}
+
+// numberMatcher builds the function which compares a number taken from a card, such as its depth, against a value such
+// as `3`, `>=2` or `2-4`.
+func numberMatcher(field, value string, get func(Card) int) (func(Card) bool, error) {
+	invalid := fmt.Errorf("invalid comparison %s:%s", field, value)
+	for _, op := range []string{">=", "<=", ">", "<"} {
+		if !strings.HasPrefix(value, op) {
+			continue
+		}
+		n, err := strconv.Atoi(value[len(op):])
+		if err != nil {
+			return nil, invalid
+		}
+		switch op {
+		case ">=":
+			return func(c Card) bool { return get(c) >= n }, nil
+		case "<=":
+			return func(c Card) bool { return get(c) <= n }, nil
+		case ">":
+			return func(c Card) bool { return get(c) > n }, nil
+		}
+		return func(c Card) bool { return get(c) < n }, nil
+	}
+	if parts := strings.SplitN(value, "-", 2); len(parts) == 2 {
+		low, err := strconv.Atoi(parts[0])
+		if err != nil {
+			return nil, invalid
+		}
+		high, err := strconv.Atoi(parts[1])
+		if err != nil {
+			return nil, invalid
+		}
+		return func(c Card) bool { return get(c) >= low && get(c) <= high }, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, invalid
+	}
+	return func(c Card) bool { return get(c) == n }, nil
+}
+
+// Matches returns whether the card satisfies every term of the query.
+func (q Query) Matches(c Card) bool {
+	for _, term := range q {
+		if term.match(c) == term.negate {
+			return false
+		}
+	}
+	return true
+}
+
+// CardsMatching returns the cards which satisfy the query, in the order they appear in the notebook.
+func (nb *Notebook) CardsMatching(q Query) []Card {
+	cards := []Card{}
+	var walk func([]Card)
+	walk = func(tree []Card) {
+		for _, c := range tree {
+			if q.Matches(c) {
+				cards = append(cards, c)
+			}
+			walk(c.Children)
+		}
+	}
+	walk(nb.GetTree())
+	return cards
+}
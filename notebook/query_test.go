@@ -0,0 +1,97 @@
+package notebook_test
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/makyo/mandelnote/notebook"
+)
+
+func TestQuery(t *testing.T) {
+	Convey("When querying cards", t, func() {
+
+		nb := notebook.New("", "Query", "", "")
+		nb.AddCard("Act 1", "The harbor at dawn", false)
+		So(nb.SetTags([]string{"ada"}), ShouldBeNil)
+		nb.AddCard("Scene 1", "Ada waits", true)
+		So(nb.SetProperty("pov", "Ada"), ShouldBeNil)
+		So(nb.SetProperty("point of view", "Ada Lovelace"), ShouldBeNil)
+		nb.AddCard("Scene 2", "Bob at the Harbor", false)
+		So(nb.SetStatus("draft"), ShouldBeNil)
+		So(nb.SetProperty("pov", "Bob"), ShouldBeNil)
+		nb.Exit()
+		nb.AddCard("Act 2", "later", false)
+
+		ids := func(query string) []string {
+			q, err := notebook.ParseQuery(query)
+			So(err, ShouldBeNil)
+			result := []string{}
+			for _, c := range nb.CardsMatching(q) {
+				result = append(result, c.ID)
+			}
+			return result
+		}
+
+		Convey("Cards know where they are", func() {
+			tree := nb.GetTree()
+			So(tree[1].Depth, ShouldEqual, 1)
+			So(tree[1].Position, ShouldEqual, 2)
+			So(tree[0].Children[1].Depth, ShouldEqual, 2)
+			So(tree[0].Children[1].Position, ShouldEqual, 2)
+		})
+
+		Convey("Structure can be queried", func() {
+			So(ids("depth:2"), ShouldResemble, []string{"c2", "c3"})
+			So(ids("depth:<2"), ShouldResemble, []string{"c1", "c4"})
+			So(ids("position:2"), ShouldResemble, []string{"c3", "c4"})
+			So(ids("position:1-2 depth:>=2"), ShouldResemble, []string{"c2", "c3"})
+			So(ids("children:2"), ShouldResemble, []string{"c1"})
+			So(ids("haschildren:no"), ShouldResemble, []string{"c2", "c3", "c4"})
+			So(ids("haschildren:yes"), ShouldResemble, []string{"c1"})
+		})
+
+		Convey("Text can be queried", func() {
+			So(ids("harbor"), ShouldResemble, []string{"c1", "c3"})
+			So(ids(`"at the harbor"`), ShouldResemble, []string{"c3"})
+			So(ids("title:/^Scene/"), ShouldResemble, []string{"c2", "c3"})
+			So(ids(`title:/^Scene\/?/`), ShouldResemble, []string{"c2", "c3"})
+			So(ids("body:ada title:scene"), ShouldResemble, []string{"c2"})
+			So(ids("depth:2 haschildren:no title:/^Scene/ \"harbor\""), ShouldResemble, []string{"c3"})
+		})
+
+		Convey("Tags, statuses and properties can be queried", func() {
+			So(ids("tag:ada"), ShouldResemble, []string{"c1"})
+			So(ids("status:draft"), ShouldResemble, []string{"c3"})
+			So(ids("prop.pov:ada"), ShouldResemble, []string{"c2"})
+			So(ids("prop.pov:"), ShouldResemble, []string{"c2", "c3"})
+			So(ids("prop.pov:/^B/"), ShouldResemble, []string{"c3"})
+			So(ids(`prop."point of view":"ada lovelace"`), ShouldResemble, []string{"c2"})
+		})
+
+		Convey("Terms can be negated", func() {
+			So(ids("!harbor"), ShouldResemble, []string{"c2", "c4"})
+			So(ids("depth:1 !tag:ada"), ShouldResemble, []string{"c4"})
+		})
+
+		Convey("Bad queries are errors", func() {
+			for query, message := range map[string]string{
+				"":                "query must not be empty",
+				"depth:x":         "invalid comparison depth:x",
+				"children:>":      "invalid comparison children:>",
+				"haschildren:abc": `haschildren must be yes or no, not "abc"`,
+				`"harbor`:         `unterminated " in query`,
+				"title:/[/":       "invalid search for title: error parsing regexp: missing closing ]: `[`",
+				"titel:x":         "unknown field titel in query; properties are queried as prop.titel",
+				"Depth:3":         "unknown field Depth in query; properties are queried as prop.Depth",
+				"prop.1st:x":      `invalid property key "1st"`,
+				`prop."pov"x`:     `expected : after property "pov" in query`,
+				`prop."pov:x`:     `unterminated " in query`,
+			} {
+				_, err := notebook.ParseQuery(query)
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldEqual, message)
+			}
+		})
+	})
+}
@@ -0,0 +1,76 @@
+package notebook
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// recoveryName returns the name of the file that unsaved changes are written to in case of a crash.
+func recoveryName(filename string) string {
+	dir, base := filepath.Split(filename)
+	return filepath.Join(dir, "."+base+".recovery")
+}
+
+// SaveRecovery writes the notebook to a recovery file next to it without touching the notebook's own file. The recovery
+// file may be read by no one who can't read the notebook.
+func (nb *Notebook) SaveRecovery() error {
+	mode := os.FileMode(0600)
+	if info, err := os.Stat(nb.filename); err == nil {
+		mode = info.Mode().Perm()
+	}
+	return writeFile(recoveryName(nb.filename), []byte(nb.Marshal()), 0, mode)
+}
+
+// HasRecovery returns whether there is a recovery file which is newer than the notebook's file, along with when it
+// was written.
+func (nb *Notebook) HasRecovery() (time.Time, bool) {
+	recovery, err := os.Stat(recoveryName(nb.filename))
+	if err != nil {
+		return time.Time{}, false
+	}
+	if saved, err := os.Stat(nb.filename); err == nil && !recovery.ModTime().After(saved.ModTime()) {
+		return time.Time{}, false
+	}
+	return recovery.ModTime(), true
+}
+
+// Recover replaces the contents of the notebook with those of its recovery file. The notebook is left dirty, since the
+// recovered changes have not been saved yet.
+func (nb *Notebook) Recover() error {
+	contents, err := ioutil.ReadFile(recoveryName(nb.filename))
+	if err != nil {
+		return err
+	}
+	recovered, err := Unmarshal(string(contents))
+	if err != nil {
+		return err
+	}
+	nb.record()
+	nb.Title = recovered.Title
+	nb.Author = recovered.Author
+	nb.Description = recovered.Description
+	nb.Revisions = recovered.Revisions
+	nb.Created = recovered.Created
+	nb.Modified = recovered.Modified
+	nb.meta = recovered.meta
+	nb.root = recovered.root
+	nb.currentCard = recovered.currentCard
+	nb.trash = recovered.trash
+	nb.statuses = recovered.statuses
+	if recovered.lastID > nb.lastID {
+		nb.lastID = recovered.lastID
+	}
+	nb.dirty = true
+	return nil
+}
+
+// DiscardRecovery removes the notebook's recovery file, if there is one.
+func (nb *Notebook) DiscardRecovery() error {
+	err := os.Remove(recoveryName(nb.filename))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
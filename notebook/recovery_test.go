@@ -0,0 +1,91 @@
+package notebook_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/makyo/mandelnote/notebook"
+)
+
+func TestRecovery(t *testing.T) {
+	Convey("When recovering unsaved changes", t, func() {
+
+		dir, err := ioutil.TempDir("", "mandelnote")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+		filename := filepath.Join(dir, "book.md")
+		recovery := filepath.Join(dir, ".book.md.recovery")
+
+		nb := notebook.New(filename, "Recovering", "", "")
+		nb.AddCard("Saved", "saved", false)
+		So(nb.Save(), ShouldBeNil)
+
+		Convey("There is nothing to recover at first", func() {
+			_, ok := nb.HasRecovery()
+			So(ok, ShouldBeFalse)
+		})
+
+		Convey("Unsaved changes can be written to a recovery file", func() {
+			nb.AddCard("Unsaved", "unsaved", false)
+			So(nb.SaveRecovery(), ShouldBeNil)
+			So(nb.Dirty(), ShouldBeTrue)
+			future := time.Now().Add(time.Minute)
+			So(os.Chtimes(recovery, future, future), ShouldBeNil)
+
+			nb2, err := notebook.Open(filename)
+			So(err, ShouldBeNil)
+			when, ok := nb2.HasRecovery()
+			So(ok, ShouldBeTrue)
+			So(when.Unix(), ShouldEqual, future.Unix())
+
+			Convey("And recovered", func() {
+				So(nb2.Recover(), ShouldBeNil)
+				So(nb2.Dirty(), ShouldBeTrue)
+				So(nb2.GetTree(), ShouldHaveLength, 2)
+				So(nb2.Undo(), ShouldBeNil)
+				So(nb2.GetTree(), ShouldHaveLength, 1)
+			})
+
+			Convey("Or discarded", func() {
+				So(nb2.DiscardRecovery(), ShouldBeNil)
+				_, ok = nb2.HasRecovery()
+				So(ok, ShouldBeFalse)
+				So(nb2.DiscardRecovery(), ShouldBeNil)
+			})
+
+			Convey("Saving removes the recovery file", func() {
+				So(nb.Save(), ShouldBeNil)
+				_, err = os.Stat(recovery)
+				So(os.IsNotExist(err), ShouldBeTrue)
+			})
+		})
+
+		Convey("Recovery files are only as readable as the notebook", func() {
+			So(os.Chmod(filename, 0600), ShouldBeNil)
+			So(ioutil.WriteFile(recovery, nil, 0644), ShouldBeNil)
+			So(nb.SaveRecovery(), ShouldBeNil)
+			info, err := os.Stat(recovery)
+			So(err, ShouldBeNil)
+			So(info.Mode().Perm(), ShouldEqual, os.FileMode(0600))
+
+			nb.SetFile(filepath.Join(dir, "new.md"))
+			So(nb.SaveRecovery(), ShouldBeNil)
+			info, err = os.Stat(filepath.Join(dir, ".new.md.recovery"))
+			So(err, ShouldBeNil)
+			So(info.Mode().Perm(), ShouldEqual, os.FileMode(0600))
+		})
+
+		Convey("Recovery files older than the notebook are ignored", func() {
+			So(nb.SaveRecovery(), ShouldBeNil)
+			past := time.Now().Add(-time.Minute)
+			So(os.Chtimes(recovery, past, past), ShouldBeNil)
+			_, ok := nb.HasRecovery()
+			So(ok, ShouldBeFalse)
+		})
+	})
+}
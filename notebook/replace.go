@@ -0,0 +1,175 @@
+package notebook
+
+import (
+	"fmt"
+	"sort"
+	"unicode/utf8"
+)
+
+// Scope is the part of the notebook that a replacement applies to.
+type Scope int
+
+const (
+	// CurrentCard is only the current card.
+	CurrentCard Scope = iota
+
+	// Subtree is the current card and all of the cards below it.
+	Subtree
+
+	// WholeNotebook is every card in the notebook.
+	WholeNotebook
+)
+
+// ReplaceOptions controls how a replacement is performed. In Regexp mode, the replacement may refer to submatches as
+// in regexp.Expand; in other modes it is used as is.
+type ReplaceOptions struct {
+	Mode   SearchMode
+	DryRun bool
+}
+
+// Change is a single replacement, giving the text that was matched and what it was replaced with, along with a snippet
+// of the surrounding text before and after the change.
+type Change struct {
+	Match
+	Text        string
+	Replacement string
+	Before      string
+	After       string
+}
+
+// snippetContext is the number of bytes either side of a change included in its snippets.
+const snippetContext = 20
+
+// snippets returns the text around a change before and after it is made.
+func snippets(text string, start, end int, replacement string) (string, string) {
+	from := start - snippetContext
+	if from < 0 {
+		from = 0
+	}
+	to := end + snippetContext
+	if to > len(text) {
+		to = len(text)
+	}
+	for from > 0 && !utf8.RuneStart(text[from]) {
+		from--
+	}
+	for to < len(text) && !utf8.RuneStart(text[to]) {
+		to++
+	}
+	return text[from:to], text[from:start] + replacement + text[end:to]
+}
+
+// inScope returns the cards within the given scope, in the order they appear in the notebook.
+func (nb *Notebook) inScope(scope Scope) []*card {
+	cards := []*card{}
+	add := func(c *card) {
+		cards = append(cards, c)
+	}
+	switch scope {
+	case CurrentCard:
+		if nb.currentCard != nb.root {
+			add(nb.currentCard)
+		}
+	case Subtree:
+		if nb.currentCard != nb.root {
+			add(nb.currentCard)
+		}
+		nb.currentCard.walk(add)
+	case WholeNotebook:
+		nb.root.walk(add)
+	}
+	return cards
+}
+
+// Replace replaces every occurrence of the pattern within the scope, returning each change made. If opts.DryRun is
+// set, the changes are returned without being made, and may be made later, perhaps only some of them, with
+// ApplyChanges.
+func (nb *Notebook) Replace(pattern, replacement string, scope Scope, opts ReplaceOptions) ([]Change, error) {
+	if scope < CurrentCard || scope > WholeNotebook {
+		return nil, fmt.Errorf("unknown scope %d", scope)
+	}
+	re, err := SearchOptions{Mode: opts.Mode}.compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	changes := []Change{}
+	for _, c := range nb.inScope(scope) {
+		path := c.path()
+		add := func(text string, inTitle bool) {
+			for _, loc := range re.FindAllStringSubmatchIndex(text, -1) {
+				if loc[0] == loc[1] {
+					continue
+				}
+				repl := replacement
+				if opts.Mode == Regexp {
+					repl = string(re.ExpandString(nil, replacement, text, loc))
+				}
+				before, after := snippets(text, loc[0], loc[1], repl)
+				changes = append(changes, Change{
+					Match: Match{
+						ID:      c.id,
+						Path:    path,
+						InTitle: inTitle,
+						Start:   loc[0],
+						End:     loc[1],
+					},
+					Text:        text[loc[0]:loc[1]],
+					Replacement: repl,
+					Before:      before,
+					After:       after,
+				})
+			}
+		}
+		add(c.title, true)
+		add(c.body, false)
+	}
+	if opts.DryRun {
+		return changes, nil
+	}
+	return changes, nb.ApplyChanges(changes)
+}
+
+// ApplyChanges makes changes returned by a dry run of Replace. The changes must not overlap, and the text they match
+// must not have been edited since, otherwise none of them are made. All of the changes are undone together.
+func (nb *Notebook) ApplyChanges(changes []Change) error {
+	if len(changes) == 0 {
+		return nil
+	}
+	for _, change := range changes {
+		c, err := nb.byID(change.ID)
+		if err != nil {
+			return err
+		}
+		text := c.body
+		if change.InTitle {
+			text = c.title
+		}
+		if change.Start < 0 || change.End > len(text) || change.Start > change.End || text[change.Start:change.End] != change.Text {
+			return fmt.Errorf("card %s has changed since %q was found", change.ID, change.Text)
+		}
+	}
+
+	// Working from the end of each piece of text back means that earlier offsets are still correct.
+	sorted := append([]Change{}, changes...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Start > sorted[j].Start
+	})
+	for i := 1; i < len(sorted); i++ {
+		for j := 0; j < i; j++ {
+			if sorted[i].ID == sorted[j].ID && sorted[i].InTitle == sorted[j].InTitle && sorted[i].End > sorted[j].Start {
+				return fmt.Errorf("changes to card %s overlap", sorted[i].ID)
+			}
+		}
+	}
+	nb.record()
+	for _, change := range sorted {
+		c, _ := nb.byID(change.ID)
+		if change.InTitle {
+			c.title = c.title[:change.Start] + change.Replacement + c.title[change.End:]
+		} else {
+			c.body = c.body[:change.Start] + change.Replacement + c.body[change.End:]
+		}
+	}
+	nb.dirty = true
+	return nil
+}
@@ -0,0 +1,108 @@
+package notebook_test
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/makyo/mandelnote/notebook"
+)
+
+func TestReplace(t *testing.T) {
+	Convey("When replacing text", t, func() {
+
+		nb := notebook.New("", "Replace", "", "")
+		nb.AddCard("Act 1", "Rose meets the Doctor.", false)
+		nb.AddCard("Rose", "Rose and the Doctor run.", true)
+		nb.AddCard("Scene 2", "The doctor, the doctor.", false)
+		nb.Exit()
+		nb.AddCard("Act 2", "Rose alone.", false)
+		So(nb.Select("c1"), ShouldBeNil)
+
+		Convey("The whole notebook can be changed", func() {
+			changes, err := nb.Replace("Rose", "Martha", notebook.WholeNotebook, notebook.ReplaceOptions{})
+			So(err, ShouldBeNil)
+			So(changes, ShouldHaveLength, 4)
+			So(changes[1].InTitle, ShouldBeTrue)
+			So(changes[3].ID, ShouldEqual, "c4")
+			So(nb.MarshalBody(), ShouldNotContainSubstring, "Rose")
+			So(nb.Dirty(), ShouldBeTrue)
+
+			Convey("And undone all at once", func() {
+				So(nb.Undo(), ShouldBeNil)
+				So(nb.MarshalBody(), ShouldNotContainSubstring, "Martha")
+			})
+		})
+
+		Convey("Changes come with snippets of the text around them", func() {
+			changes, err := nb.Replace("doctor", "Doctor", notebook.Subtree, notebook.ReplaceOptions{})
+			So(err, ShouldBeNil)
+			So(changes, ShouldHaveLength, 2)
+			So(changes[0].Text, ShouldEqual, "doctor")
+			So(changes[0].Before, ShouldEqual, "The doctor, the doctor.")
+			So(changes[0].After, ShouldEqual, "The Doctor, the doctor.")
+			So(changes[1].After, ShouldEqual, "The doctor, the Doctor.")
+			title, body := nb.GetCard()
+			So(title, ShouldEqual, "Act 1")
+			So(body, ShouldEqual, "Rose meets the Doctor.")
+		})
+
+		Convey("Scope can be limited to the current card", func() {
+			changes, err := nb.Replace("Rose", "Martha", notebook.CurrentCard, notebook.ReplaceOptions{})
+			So(err, ShouldBeNil)
+			So(changes, ShouldHaveLength, 1)
+			_, body := nb.GetCard()
+			So(body, ShouldEqual, "Martha meets the Doctor.")
+		})
+
+		Convey("Regular expressions can refer to submatches", func() {
+			changes, err := nb.Replace(`the (\w+)`, "a $1", notebook.CurrentCard, notebook.ReplaceOptions{Mode: notebook.Regexp})
+			So(err, ShouldBeNil)
+			So(changes[0].Replacement, ShouldEqual, "a Doctor")
+			_, body := nb.GetCard()
+			So(body, ShouldEqual, "Rose meets a Doctor.")
+		})
+
+		Convey("Dry runs change nothing", func() {
+			nb, err := notebook.Unmarshal(nb.Marshal())
+			So(err, ShouldBeNil)
+			So(nb.Select("c1"), ShouldBeNil)
+			So(nb.CanUndo(), ShouldBeFalse)
+			So(nb.Dirty(), ShouldBeFalse)
+			before := nb.MarshalBody()
+			changes, err := nb.Replace("rose", "Martha", notebook.WholeNotebook, notebook.ReplaceOptions{
+				Mode:   notebook.CaseInsensitive,
+				DryRun: true,
+			})
+			So(err, ShouldBeNil)
+			So(changes, ShouldHaveLength, 4)
+			So(nb.MarshalBody(), ShouldEqual, before)
+			So(nb.CanUndo(), ShouldBeFalse)
+			So(nb.Dirty(), ShouldBeFalse)
+
+			Convey("But their changes can be applied later", func() {
+				So(nb.ApplyChanges([]notebook.Change{changes[0], changes[3]}), ShouldBeNil)
+				So(nb.MarshalBody(), ShouldContainSubstring, "Martha meets")
+				So(nb.MarshalBody(), ShouldContainSubstring, "Martha alone")
+				So(nb.MarshalBody(), ShouldContainSubstring, "# Rose {#c2}")
+			})
+
+			Convey("Unless the text has changed", func() {
+				nb.EditCard("Act 1", "Someone else")
+				So(nb.ApplyChanges(changes).Error(), ShouldEqual, `card c1 has changed since "Rose" was found`)
+				So(nb.MarshalBody(), ShouldContainSubstring, "Rose alone")
+			})
+
+			Convey("Or the changes overlap", func() {
+				So(nb.ApplyChanges([]notebook.Change{changes[0], changes[0]}).Error(), ShouldEqual, "changes to card c1 overlap")
+			})
+		})
+
+		Convey("Bad arguments are errors", func() {
+			_, err := nb.Replace("", "Martha", notebook.WholeNotebook, notebook.ReplaceOptions{})
+			So(err.Error(), ShouldEqual, "search query must not be empty")
+			_, err = nb.Replace("Rose", "Martha", notebook.Scope(7), notebook.ReplaceOptions{})
+			So(err.Error(), ShouldEqual, "unknown scope 7")
+		})
+	})
+}
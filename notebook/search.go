@@ -0,0 +1,81 @@
+package notebook
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// SearchMode is how a search query is matched against the text of cards.
+type SearchMode int
+
+const (
+	// Plain matches the query exactly.
+	Plain SearchMode = iota
+
+	// CaseInsensitive matches the query regardless of case.
+	CaseInsensitive
+
+	// Regexp treats the query as a regular expression.
+	Regexp
+)
+
+// SearchOptions controls how a search is performed.
+type SearchOptions struct {
+	Mode SearchMode
+}
+
+// Match is a single search result, giving the card it was found in and the byte offsets of the match within either the
+// card's title or its body.
+type Match struct {
+	ID      string
+	Path    []int
+	InTitle bool
+	Start   int
+	End     int
+}
+
+// compile builds the regular expression for a query.
+func (opts SearchOptions) compile(query string) (*regexp.Regexp, error) {
+	if query == "" {
+		return nil, fmt.Errorf("search query must not be empty")
+	}
+	switch opts.Mode {
+	case Plain:
+		return regexp.Compile(regexp.QuoteMeta(query))
+	case CaseInsensitive:
+		return regexp.Compile("(?i)" + regexp.QuoteMeta(query))
+	case Regexp:
+		return regexp.Compile(query)
+	}
+	return nil, fmt.Errorf("unknown search mode %d", opts.Mode)
+}
+
+// Search finds every occurrence of the query in the titles and bodies of the notebook's cards, in the order that the
+// cards appear in the notebook. Matches which contain no text are skipped.
+func (nb *Notebook) Search(query string, opts SearchOptions) ([]Match, error) {
+	re, err := opts.compile(query)
+	if err != nil {
+		return nil, err
+	}
+	matches := []Match{}
+	nb.root.walk(func(c *card) {
+		path := c.path()
+		add := func(text string, inTitle bool) {
+			for _, loc := range re.FindAllStringIndex(text, -1) {
+				if loc[0] == loc[1] {
+					continue
+				}
+				matches = append(matches, Match{
+					ID:      c.id,
+					Path:    path,
+					InTitle: inTitle,
+					Start:   loc[0],
+					End:     loc[1],
+				})
+			}
+		}
+		add(c.title, true)
+		add(c.body, false)
+	})
+	return matches, nil
+}
@@ -0,0 +1,71 @@
+package notebook_test
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/makyo/mandelnote/notebook"
+)
+
+func TestSearch(t *testing.T) {
+	Convey("When searching a notebook", t, func() {
+
+		nb := notebook.New("", "Search", "", "")
+		nb.AddCard("Act 1", "Rose meets the Doctor.", false)
+		nb.AddCard("Scene 1", "The doctor runs.", true)
+		nb.AddCard("Rose", "Rose and the Doctor run. Doctor!", false)
+		nb.Exit()
+		nb.AddCard("Act 2", "Nothing here.", false)
+
+		Convey("Plain searches match exactly", func() {
+			matches, err := nb.Search("Doctor", notebook.SearchOptions{})
+			So(err, ShouldBeNil)
+			So(matches, ShouldResemble, []notebook.Match{
+				{ID: "c1", Path: []int{0}, Start: 15, End: 21},
+				{ID: "c3", Path: []int{0, 1}, Start: 13, End: 19},
+				{ID: "c3", Path: []int{0, 1}, Start: 25, End: 31},
+			})
+		})
+
+		Convey("Case-insensitive searches ignore case", func() {
+			matches, err := nb.Search("rose", notebook.SearchOptions{Mode: notebook.CaseInsensitive})
+			So(err, ShouldBeNil)
+			So(matches, ShouldResemble, []notebook.Match{
+				{ID: "c1", Path: []int{0}, Start: 0, End: 4},
+				{ID: "c3", Path: []int{0, 1}, InTitle: true, Start: 0, End: 4},
+				{ID: "c3", Path: []int{0, 1}, Start: 0, End: 4},
+			})
+		})
+
+		Convey("Regular expressions can be used", func() {
+			matches, err := nb.Search(`[Aa]ct \d`, notebook.SearchOptions{Mode: notebook.Regexp})
+			So(err, ShouldBeNil)
+			So(matches, ShouldHaveLength, 2)
+			So(matches[1].ID, ShouldEqual, "c4")
+			So(matches[1].InTitle, ShouldBeTrue)
+
+			Convey("Though empty matches are skipped", func() {
+				matches, err = nb.Search(`x*`, notebook.SearchOptions{Mode: notebook.Regexp})
+				So(err, ShouldBeNil)
+				So(matches, ShouldBeEmpty)
+			})
+		})
+
+		Convey("Matches can be selected", func() {
+			matches, _ := nb.Search("runs", notebook.SearchOptions{})
+			So(nb.SelectPath(matches[0].Path), ShouldBeNil)
+			title, _ := nb.GetCard()
+			So(title, ShouldEqual, "Scene 1")
+		})
+
+		Convey("Bad queries are errors", func() {
+			_, err := nb.Search("", notebook.SearchOptions{})
+			So(err.Error(), ShouldEqual, "search query must not be empty")
+			_, err = nb.Search("(", notebook.SearchOptions{Mode: notebook.Regexp})
+			So(err, ShouldNotBeNil)
+			_, err = nb.Search("(", notebook.SearchOptions{})
+			So(err, ShouldBeNil)
+		})
+	})
+}
@@ -0,0 +1,101 @@
+package notebook
+
+import "fmt"
+
+// DefaultStatuses are the stages that cards move through unless the notebook sets its own.
+var DefaultStatuses = []string{"idea", "outline", "draft", "revised", "final"}
+
+// Statuses returns a copy of the stages that cards in the notebook move through, in order.
+func (nb *Notebook) Statuses() []string {
+	if len(nb.statuses) == 0 {
+		return append([]string{}, DefaultStatuses...)
+	}
+	return append([]string{}, nb.statuses...)
+}
+
+// SetStatuses sets the stages that cards in the notebook move through. Cards keep their status even if it is no longer
+// in the list.
+func (nb *Notebook) SetStatuses(statuses []string) error {
+	if len(statuses) == 0 {
+		return fmt.Errorf("there must be at least one status")
+	}
+	seen := map[string]bool{}
+	for _, status := range statuses {
+		if !validValue(status) {
+			return fmt.Errorf("invalid status %q", status)
+		}
+		if seen[status] {
+			return fmt.Errorf("duplicate status %s", status)
+		}
+		seen[status] = true
+	}
+	nb.record()
+	nb.statuses = append([]string{}, statuses...)
+	nb.dirty = true
+	return nil
+}
+
+// StatusIndex returns the position of a status in the notebook's list, or -1 if it is not there.
+func (nb *Notebook) StatusIndex(status string) int {
+	for i, s := range nb.Statuses() {
+		if s == status {
+			return i
+		}
+	}
+	return -1
+}
+
+// SetStatus sets the status of the current card. An empty status removes it.
+func (nb *Notebook) SetStatus(status string) error {
+	if nb.currentCard == nb.root {
+		return fmt.Errorf("no card to set a status on")
+	}
+	if status != "" && nb.StatusIndex(status) == -1 {
+		return fmt.Errorf("unknown status %s", status)
+	}
+	if status == nb.currentCard.status {
+		return nil
+	}
+	nb.record()
+	nb.currentCard.status = status
+	nb.dirty = true
+	return nil
+}
+
+// AdvanceStatus moves the current card the given number of stages along the list of statuses, or back if negative.
+// Cards without a status start before the first, and moving back past the first removes the status.
+func (nb *Notebook) AdvanceStatus(steps int) error {
+	if nb.currentCard == nb.root {
+		return fmt.Errorf("no card to set a status on")
+	}
+	index := -1
+	if nb.currentCard.status != "" {
+		index = nb.StatusIndex(nb.currentCard.status)
+		if index == -1 {
+			return fmt.Errorf("unknown status %s", nb.currentCard.status)
+		}
+	}
+	index += steps
+	if index < -1 {
+		index = -1
+	}
+	if index >= len(nb.Statuses()) {
+		index = len(nb.Statuses()) - 1
+	}
+	status := ""
+	if index >= 0 {
+		status = nb.Statuses()[index]
+	}
+	return nb.SetStatus(status)
+}
+
+// CardsWithStatus returns the IDs of the cards with the given status, in the order they appear in the notebook.
+func (nb *Notebook) CardsWithStatus(status string) []string {
+	ids := []string{}
+	nb.root.walk(func(c *card) {
+		if c.status == status {
+			ids = append(ids, c.id)
+		}
+	})
+	return ids
+}
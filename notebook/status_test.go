@@ -0,0 +1,104 @@
+package notebook_test
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/makyo/mandelnote/notebook"
+)
+
+func TestStatus(t *testing.T) {
+	Convey("When moving cards through statuses", t, func() {
+
+		nb := notebook.New("", "Statuses", "", "")
+		nb.AddCard("Act 1", "one", false)
+		nb.AddCard("Scene 1", "1.1", true)
+		nb.AddCard("Scene 2", "1.2", false)
+		nb.Exit()
+
+		status := func() string {
+			c, _ := nb.Lookup(nb.CurrentID())
+			return c.Status
+		}
+
+		Convey("Notebooks start with the default statuses", func() {
+			So(nb.Statuses(), ShouldResemble, notebook.DefaultStatuses)
+			nb.Statuses()[0] = "changed"
+			So(notebook.DefaultStatuses[0], ShouldEqual, "idea")
+			So(status(), ShouldEqual, "")
+			So(nb.StatusIndex("draft"), ShouldEqual, 2)
+			So(nb.StatusIndex("nope"), ShouldEqual, -1)
+		})
+
+		Convey("Statuses can be set and are kept in the header", func() {
+			So(nb.SetStatus("draft"), ShouldBeNil)
+			So(status(), ShouldEqual, "draft")
+			So(nb.MarshalBody(), ShouldContainSubstring, "# Act 1 {#c1 status=draft}\n")
+			nb2, err := notebook.Unmarshal(nb.Marshal())
+			So(err, ShouldBeNil)
+			So(nb2.GetTree()[0].Status, ShouldEqual, "draft")
+
+			Convey("And removed", func() {
+				So(nb.SetStatus(""), ShouldBeNil)
+				So(nb.MarshalBody(), ShouldContainSubstring, "# Act 1 {#c1}\n")
+			})
+		})
+
+		Convey("Statuses can be advanced and moved back", func() {
+			So(nb.AdvanceStatus(1), ShouldBeNil)
+			So(status(), ShouldEqual, "idea")
+			So(nb.AdvanceStatus(2), ShouldBeNil)
+			So(status(), ShouldEqual, "draft")
+			So(nb.AdvanceStatus(10), ShouldBeNil)
+			So(status(), ShouldEqual, "final")
+			So(nb.AdvanceStatus(-1), ShouldBeNil)
+			So(status(), ShouldEqual, "revised")
+			So(nb.AdvanceStatus(-10), ShouldBeNil)
+			So(status(), ShouldEqual, "")
+
+			Convey("And the changes undone", func() {
+				So(nb.Undo(), ShouldBeNil)
+				So(status(), ShouldEqual, "revised")
+			})
+		})
+
+		Convey("Cards can be found by status", func() {
+			So(nb.SetStatus("idea"), ShouldBeNil)
+			So(nb.Select("c3"), ShouldBeNil)
+			So(nb.SetStatus("idea"), ShouldBeNil)
+			So(nb.CardsWithStatus("idea"), ShouldResemble, []string{"c1", "c3"})
+			So(nb.CardsWithStatus("final"), ShouldBeEmpty)
+		})
+
+		Convey("Notebooks can have their own statuses", func() {
+			So(nb.SetStatuses([]string{"todo", "done"}), ShouldBeNil)
+			So(nb.Marshal(), ShouldContainSubstring, "statuses:\n- todo\n- done\n")
+			nb2, err := notebook.Unmarshal(nb.Marshal())
+			So(err, ShouldBeNil)
+			So(nb2.Statuses(), ShouldResemble, []string{"todo", "done"})
+			nb.Statuses()[0] = "changed"
+			So(nb.Statuses(), ShouldResemble, []string{"todo", "done"})
+			So(nb.SetStatus("draft").Error(), ShouldEqual, "unknown status draft")
+			So(nb.Undo(), ShouldBeNil)
+			So(nb.Statuses(), ShouldResemble, notebook.DefaultStatuses)
+			So(nb.Marshal(), ShouldNotContainSubstring, "statuses:")
+		})
+
+		Convey("Cards keep statuses which are no longer in the list", func() {
+			So(nb.SetStatus("draft"), ShouldBeNil)
+			So(nb.SetStatuses([]string{"todo", "done"}), ShouldBeNil)
+			So(status(), ShouldEqual, "draft")
+			So(nb.AdvanceStatus(1).Error(), ShouldEqual, "unknown status draft")
+		})
+
+		Convey("Bad statuses are errors", func() {
+			So(nb.SetStatuses(nil).Error(), ShouldEqual, "there must be at least one status")
+			So(nb.SetStatuses([]string{"to do"}).Error(), ShouldEqual, `invalid status "to do"`)
+			So(nb.SetStatuses([]string{"a", "a"}).Error(), ShouldEqual, "duplicate status a")
+			So(notebook.New("", "Empty", "", "").SetStatus("idea").Error(), ShouldEqual, "no card to set a status on")
+			_, err := notebook.Unmarshal("---\nstatuses: [\"a b\"]\n---\n")
+			So(err.Error(), ShouldEqual, `malformed notebook; invalid status "a b"`)
+		})
+	})
+}
@@ -0,0 +1,130 @@
+package notebook
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// uniqueTags returns the tags with any repeats removed, keeping them in order.
+func uniqueTags(tags []string) []string {
+	result := []string{}
+	seen := map[string]bool{}
+	for _, tag := range tags {
+		if !seen[tag] {
+			result = append(result, tag)
+			seen[tag] = true
+		}
+	}
+	return result
+}
+
+// hasTag returns whether the tags include the one given.
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// checkTags returns the tags with any repeats removed, or an error if any of them is invalid.
+func checkTags(tags []string) ([]string, error) {
+	for _, tag := range tags {
+		if !validValue(tag) {
+			return nil, fmt.Errorf("invalid tag %q", tag)
+		}
+	}
+	return uniqueTags(tags), nil
+}
+
+// ParseTags reads tags separated by whitespace, as they are entered in the editor.
+func ParseTags(text string) ([]string, error) {
+	return checkTags(strings.Fields(text))
+}
+
+// SetTags replaces the tags of the current card. Repeated tags are only kept once.
+func (nb *Notebook) SetTags(tags []string) error {
+	if nb.currentCard == nb.root {
+		return fmt.Errorf("no card to tag")
+	}
+	tags, err := checkTags(tags)
+	if err != nil {
+		return err
+	}
+	if strings.Join(tags, " ") == strings.Join(nb.currentCard.tags, " ") {
+		return nil
+	}
+	nb.record()
+	nb.currentCard.tags = tags
+	nb.dirty = true
+	return nil
+}
+
+// Tags returns every tag used in the notebook, sorted.
+func (nb *Notebook) Tags() []string {
+	seen := map[string]bool{}
+	nb.root.walk(func(c *card) {
+		for _, tag := range c.tags {
+			seen[tag] = true
+		}
+	})
+	tags := []string{}
+	for tag := range seen {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// CardsWithTag returns the IDs of the cards with the given tag, in the order they appear in the notebook.
+func (nb *Notebook) CardsWithTag(tag string) []string {
+	ids := []string{}
+	nb.root.walk(func(c *card) {
+		if hasTag(c.tags, tag) {
+			ids = append(ids, c.id)
+		}
+	})
+	return ids
+}
+
+// TagExpression matches cards by their tags. It is made up of alternatives separated by `|`, each of which is a list of
+// tags separated by spaces which must all be present, or absent if preceded by `!`. For example, `ada !harbor | bob`
+// matches cards tagged ada but not harbor, along with those tagged bob.
+type TagExpression [][]string
+
+// ParseTagExpression reads a tag expression.
+func ParseTagExpression(expr string) (TagExpression, error) {
+	result := TagExpression{}
+	for _, alternative := range strings.Split(expr, "|") {
+		terms := strings.Fields(alternative)
+		if len(terms) == 0 {
+			return nil, fmt.Errorf("empty tag expression")
+		}
+		for _, term := range terms {
+			if !validValue(strings.TrimPrefix(term, "!")) {
+				return nil, fmt.Errorf("invalid tag %q", term)
+			}
+		}
+		result = append(result, terms)
+	}
+	return result, nil
+}
+
+// Matches returns whether the tags satisfy the expression.
+func (expr TagExpression) Matches(tags []string) bool {
+	for _, alternative := range expr {
+		matched := true
+		for _, term := range alternative {
+			if strings.HasPrefix(term, "!") == hasTag(tags, strings.TrimPrefix(term, "!")) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
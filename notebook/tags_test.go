@@ -0,0 +1,71 @@
+package notebook_test
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/makyo/mandelnote/notebook"
+)
+
+func TestTags(t *testing.T) {
+	Convey("When tagging cards", t, func() {
+
+		nb := notebook.New("", "Tags", "", "")
+		nb.AddCard("Act 1", "one", false)
+		So(nb.SetTags([]string{"ada", "harbor", "ada"}), ShouldBeNil)
+		nb.AddCard("Scene 1", "1.1", true)
+		So(nb.SetTags([]string{"bob"}), ShouldBeNil)
+		nb.AddCard("Scene 2", "1.2", false)
+		So(nb.SetTags([]string{"ada"}), ShouldBeNil)
+		nb.Exit()
+
+		Convey("Tags are kept in the header", func() {
+			So(nb.MarshalBody(), ShouldContainSubstring, "# Act 1 {#c1 .ada .harbor}\n")
+			nb2, err := notebook.Unmarshal(nb.Marshal())
+			So(err, ShouldBeNil)
+			So(nb2.GetTree(), ShouldResemble, nb.GetTree())
+		})
+
+		Convey("Along with other attributes", func() {
+			So(nb.SetStatus("draft"), ShouldBeNil)
+			So(nb.SetTarget(100), ShouldBeNil)
+			So(nb.MarshalBody(), ShouldContainSubstring, "# Act 1 {#c1 .ada .harbor target=100 status=draft}\n")
+			nb2, err := notebook.Unmarshal(nb.Marshal())
+			So(err, ShouldBeNil)
+			So(nb2.GetTree()[0].Tags, ShouldResemble, []string{"ada", "harbor"})
+		})
+
+		Convey("Cards can be found by tag", func() {
+			So(nb.Tags(), ShouldResemble, []string{"ada", "bob", "harbor"})
+			So(nb.CardsWithTag("ada"), ShouldResemble, []string{"c1", "c3"})
+			So(nb.CardsWithTag("nobody"), ShouldBeEmpty)
+		})
+
+		Convey("Tags can be removed and the change undone", func() {
+			So(nb.SetTags(nil), ShouldBeNil)
+			So(nb.MarshalBody(), ShouldContainSubstring, "# Act 1 {#c1}\n")
+			So(nb.Undo(), ShouldBeNil)
+			So(nb.GetTree()[0].Tags, ShouldResemble, []string{"ada", "harbor"})
+		})
+
+		Convey("Tag expressions match cards", func() {
+			expr, err := notebook.ParseTagExpression("ada !harbor | bob")
+			So(err, ShouldBeNil)
+			So(expr.Matches([]string{"ada", "harbor"}), ShouldBeFalse)
+			So(expr.Matches([]string{"ada"}), ShouldBeTrue)
+			So(expr.Matches([]string{"bob", "harbor"}), ShouldBeTrue)
+			So(expr.Matches(nil), ShouldBeFalse)
+
+			_, err = notebook.ParseTagExpression("ada |")
+			So(err.Error(), ShouldEqual, "empty tag expression")
+			_, err = notebook.ParseTagExpression("!{x}")
+			So(err.Error(), ShouldEqual, `invalid tag "!{x}"`)
+		})
+
+		Convey("Bad tags are errors", func() {
+			So(nb.SetTags([]string{"two words"}).Error(), ShouldEqual, `invalid tag "two words"`)
+			So(notebook.New("", "Empty", "", "").SetTags([]string{"a"}).Error(), ShouldEqual, "no card to tag")
+		})
+	})
+}
@@ -0,0 +1,37 @@
+package notebook
+
+import "fmt"
+
+// SetTarget sets the number of words the current card is meant to have. A target of zero removes it.
+func (nb *Notebook) SetTarget(words int) error {
+	if nb.currentCard == nb.root {
+		return fmt.Errorf("no card to set a target on")
+	}
+	if words < 0 {
+		return fmt.Errorf("target must not be negative")
+	}
+	if words == nb.currentCard.target {
+		return nil
+	}
+	nb.record()
+	nb.currentCard.target = words
+	nb.dirty = true
+	return nil
+}
+
+// Target returns the number of words the notebook is meant to have, rolled up from the targets of its cards.
+func (nb *Notebook) Target() int {
+	total := 0
+	for _, c := range nb.GetTree() {
+		total += c.TotalTarget
+	}
+	return total
+}
+
+// Progress returns how far the words counted have come towards a target as a percentage, or -1 if there is no target.
+func Progress(words, target int) int {
+	if target <= 0 {
+		return -1
+	}
+	return words * 100 / target
+}
@@ -0,0 +1,72 @@
+package notebook_test
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/makyo/mandelnote/notebook"
+)
+
+func TestTarget(t *testing.T) {
+	Convey("When setting targets", t, func() {
+
+		nb := notebook.New("", "Targets", "", "")
+		nb.AddCard("Act 1", "one two", false)
+		nb.AddCard("Scene 1", "three four five", true)
+		So(nb.SetTarget(10), ShouldBeNil)
+		nb.AddCard("Scene 2", "six", false)
+		So(nb.SetTarget(20), ShouldBeNil)
+		nb.Exit()
+		nb.AddCard("Act 2", "", false)
+
+		Convey("Targets are kept in the header", func() {
+			So(nb.MarshalBody(), ShouldContainSubstring, "## Scene 1 {#c2 target=10}\n")
+			nb2, err := notebook.Unmarshal(nb.Marshal())
+			So(err, ShouldBeNil)
+			So(nb2.Select("c4"), ShouldBeNil)
+			So(nb2.GetTree(), ShouldResemble, nb.GetTree())
+		})
+
+		Convey("Parents roll up the targets of their children", func() {
+			tree := nb.GetTree()
+			So(tree[0].Target, ShouldEqual, 0)
+			So(tree[0].TotalTarget, ShouldEqual, 30)
+			So(tree[0].Children[0].TotalTarget, ShouldEqual, 10)
+			So(nb.Target(), ShouldEqual, 30)
+
+			Convey("Unless they have their own", func() {
+				So(nb.Select("c1"), ShouldBeNil)
+				So(nb.SetTarget(100), ShouldBeNil)
+				So(nb.GetTree()[0].TotalTarget, ShouldEqual, 100)
+				So(nb.Target(), ShouldEqual, 100)
+			})
+		})
+
+		Convey("Progress is a percentage of the target", func() {
+			tree := nb.GetTree()
+			So(notebook.Progress(tree[0].Total.Words, tree[0].TotalTarget), ShouldEqual, 20)
+			So(notebook.Progress(tree[1].Total.Words, tree[1].TotalTarget), ShouldEqual, -1)
+		})
+
+		Convey("Targets can be removed and the change undone", func() {
+			So(nb.Select("c2"), ShouldBeNil)
+			So(nb.SetTarget(0), ShouldBeNil)
+			So(nb.MarshalBody(), ShouldContainSubstring, "## Scene 1 {#c2}\n")
+			So(nb.Undo(), ShouldBeNil)
+			So(nb.MarshalBody(), ShouldContainSubstring, "## Scene 1 {#c2 target=10}\n")
+		})
+
+		Convey("Bad targets are errors", func() {
+			So(nb.SetTarget(-1).Error(), ShouldEqual, "target must not be negative")
+			So(notebook.New("", "Empty", "", "").SetTarget(1).Error(), ShouldEqual, "no card to set a target on")
+		})
+
+		Convey("Headers with bad targets keep them in their titles", func() {
+			nb2, err := notebook.Unmarshal("---\n---\n# Title {#c1 target=lots}\n")
+			So(err, ShouldBeNil)
+			title, _ := nb2.GetCard()
+			So(title, ShouldEqual, "Title {#c1 target=lots}")
+		})
+	})
+}
@@ -0,0 +1,182 @@
+package notebook
+
+import (
+	"fmt"
+	"time"
+)
+
+// trashed is a deleted card, along with its children and where it was deleted from.
+type trashed struct {
+	card     *card
+	parent   string
+	position int
+	deleted  time.Time
+}
+
+// trashEntry is how a deleted card is stored in the notebook's front matter.
+type trashEntry struct {
+	Parent   string
+	Position int
+	Deleted  time.Time
+	Cards    string
+}
+
+// TrashedCard is a deleted card, along with the ID of the card it was deleted from (empty for top-level cards) and its
+// position among that card's children.
+type TrashedCard struct {
+	Card     Card
+	Parent   string
+	Position int
+	Deleted  time.Time
+}
+
+// cloneTrash returns a copy of the trash which shares no cards with the original.
+func cloneTrash(trash []trashed) []trashed {
+	result := make([]trashed, len(trash))
+	for i, t := range trash {
+		result[i] = t
+		result[i].card = t.card.clone(nil)
+	}
+	return result
+}
+
+// moveToTrash detaches the card from the tree and keeps it in the trash. The card's links are cleared, so it must not
+// be the current card.
+func (nb *Notebook) moveToTrash(c *card) {
+	position := 0
+	for sibling := c.prev; sibling != nil; sibling = sibling.prev {
+		position++
+	}
+	if c.prev == nil {
+		c.parent.firstChild = c.next
+	} else {
+		c.prev.next = c.next
+	}
+	if c.next != nil {
+		c.next.prev = c.prev
+	}
+	nb.trash = append(nb.trash, trashed{
+		card:     c,
+		parent:   c.parent.id,
+		position: position,
+		deleted:  time.Now(),
+	})
+	c.parent, c.prev, c.next = nil, nil, nil
+}
+
+// trashEntries converts the trash for storing in the front matter.
+func (nb *Notebook) trashEntries() []trashEntry {
+	entries := make([]trashEntry, len(nb.trash))
+	for i, t := range nb.trash {
+		entries[i] = trashEntry{
+			Parent:   t.parent,
+			Position: t.position,
+			Deleted:  t.deleted,
+			Cards:    t.card.Marshal(1),
+		}
+	}
+	return entries
+}
+
+// readTrash reads the trash from the entries stored in the front matter.
+func (nb *Notebook) readTrash(entries []trashEntry) error {
+	for _, entry := range entries {
+		root := &card{}
+		p := &parser{
+			root:    root,
+			current: root,
+		}
+		if err := p.parse(entry.Cards); err != nil {
+			return fmt.Errorf("malformed trash: %v", err)
+		}
+		if root.firstChild == nil || root.firstChild.next != nil {
+			return fmt.Errorf("malformed trash; each entry must hold exactly one card")
+		}
+		c := root.firstChild
+		c.parent = nil
+		nb.trash = append(nb.trash, trashed{
+			card:     c,
+			parent:   entry.Parent,
+			position: entry.Position,
+			deleted:  entry.Deleted,
+		})
+	}
+	return nil
+}
+
+// Trash returns the deleted cards, most recently deleted last.
+func (nb *Notebook) Trash() []TrashedCard {
+	result := []TrashedCard{}
+	for _, t := range nb.trash {
+		result = append(result, TrashedCard{
+			Card:     t.card.snapshot(nb, 0, 0),
+			Parent:   t.parent,
+			Position: t.position,
+			Deleted:  t.deleted,
+		})
+	}
+	return result
+}
+
+// Restore puts a deleted card back where it was deleted from and makes it the current card. If the card it was
+// deleted from no longer exists, it is restored to the end of the top level instead.
+func (nb *Notebook) Restore(id string) error {
+	index := -1
+	for i, t := range nb.trash {
+		if t.card.id == id {
+			index = i
+		}
+	}
+	if index == -1 {
+		return fmt.Errorf("no card with ID %s in the trash", id)
+	}
+	nb.record()
+	t := nb.trash[index]
+	nb.trash = append(nb.trash[:index:index], nb.trash[index+1:]...)
+
+	parent := nb.root
+	position := t.position
+	if t.parent != "" {
+		if p := nb.root.find(t.parent); p != nil {
+			parent = p
+		} else {
+			position = -1
+		}
+	}
+	c := t.card
+
+	c.parent = parent
+	if parent.firstChild == nil || position == 0 {
+		c.next = parent.firstChild
+		if c.next != nil {
+			c.next.prev = c
+		}
+		parent.firstChild = c
+	} else {
+		prev := parent.firstChild
+		for ; prev.next != nil && position != 1; position-- {
+			prev = prev.next
+		}
+		c.prev = prev
+		c.next = prev.next
+		if c.next != nil {
+			c.next.prev = c
+		}
+		prev.next = c
+	}
+
+	nb.currentCard = c
+	nb.dirty = true
+	return nil
+}
+
+// EmptyTrash permanently removes all deleted cards.
+func (nb *Notebook) EmptyTrash() error {
+	if len(nb.trash) == 0 {
+		return fmt.Errorf("trash is already empty")
+	}
+	nb.record()
+	nb.trash = nil
+	nb.dirty = true
+	return nil
+}
@@ -0,0 +1,120 @@
+package notebook_test
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/makyo/mandelnote/notebook"
+)
+
+func TestTrash(t *testing.T) {
+	Convey("When deleting cards", t, func() {
+
+		nb := notebook.New("", "Trash", "", "")
+		nb.AddCard("Act 1", "one", false)
+		nb.AddCard("Scene 1", "1.1", true)
+		nb.AddCard("Scene 2", "1.2", false)
+		nb.AddCard("Scene 3", "1.3", false)
+		nb.Exit()
+		nb.AddCard("Act 2", "two", false)
+		before := nb.MarshalBody()
+
+		Convey("The trash starts out empty", func() {
+			So(nb.Trash(), ShouldBeEmpty)
+			So(nb.EmptyTrash().Error(), ShouldEqual, "trash is already empty")
+		})
+
+		Convey("Deleted cards go to the trash along with their children", func() {
+			So(nb.Select("c1"), ShouldBeNil)
+			So(nb.Delete(true), ShouldBeNil)
+			trash := nb.Trash()
+			So(trash, ShouldHaveLength, 1)
+			So(trash[0].Card.Title, ShouldEqual, "Act 1")
+			So(trash[0].Card.Children, ShouldHaveLength, 3)
+			So(trash[0].Parent, ShouldEqual, "")
+			So(trash[0].Position, ShouldEqual, 0)
+
+			Convey("And can be restored to where they were", func() {
+				So(nb.Restore("c1"), ShouldBeNil)
+				So(nb.MarshalBody(), ShouldEqual, before)
+				So(nb.CurrentID(), ShouldEqual, "c1")
+				So(nb.Trash(), ShouldBeEmpty)
+			})
+
+			Convey("Restoring can be undone", func() {
+				So(nb.Restore("c1"), ShouldBeNil)
+				So(nb.Undo(), ShouldBeNil)
+				So(nb.Trash(), ShouldHaveLength, 1)
+				So(nb.GetTree(), ShouldHaveLength, 1)
+			})
+
+			Convey("Only cards in the trash can be restored", func() {
+				So(nb.Restore("c5").Error(), ShouldEqual, "no card with ID c5 in the trash")
+			})
+
+			Convey("New cards don't reuse the IDs of those in the trash", func() {
+				nb.AddCard("Act 3", "three", false)
+				So(nb.CurrentID(), ShouldEqual, "c6")
+			})
+
+			Convey("The trash can be emptied", func() {
+				So(nb.EmptyTrash(), ShouldBeNil)
+				So(nb.Trash(), ShouldBeEmpty)
+				So(nb.Undo(), ShouldBeNil)
+				So(nb.Trash(), ShouldHaveLength, 1)
+			})
+		})
+
+		Convey("Cards are restored among their siblings", func() {
+			So(nb.Select("c3"), ShouldBeNil)
+			So(nb.Delete(false), ShouldBeNil)
+			trash := nb.Trash()
+			So(trash[0].Parent, ShouldEqual, "c1")
+			So(trash[0].Position, ShouldEqual, 1)
+			So(nb.Restore("c3"), ShouldBeNil)
+			So(nb.MarshalBody(), ShouldEqual, before)
+		})
+
+		Convey("Cards whose parent is gone are restored to the top level", func() {
+			So(nb.Select("c3"), ShouldBeNil)
+			So(nb.Delete(false), ShouldBeNil)
+			So(nb.Select("c1"), ShouldBeNil)
+			So(nb.Delete(true), ShouldBeNil)
+			So(nb.Restore("c3"), ShouldBeNil)
+			So(nb.CurrentPath(), ShouldResemble, []int{1})
+		})
+
+		Convey("Deleting and undoing leaves the trash as it was", func() {
+			So(nb.Delete(false), ShouldBeNil)
+			So(nb.Undo(), ShouldBeNil)
+			So(nb.Trash(), ShouldBeEmpty)
+			So(nb.MarshalBody(), ShouldEqual, before)
+		})
+
+		Convey("The trash is saved with the notebook", func() {
+			So(nb.Select("c1"), ShouldBeNil)
+			So(nb.Delete(true), ShouldBeNil)
+			So(nb.Marshal(), ShouldContainSubstring, "trash:")
+			nb2, err := notebook.Unmarshal(nb.Marshal())
+			So(err, ShouldBeNil)
+			trash, trash2 := nb.Trash(), nb2.Trash()
+			So(trash2, ShouldHaveLength, 1)
+			So(trash2[0].Card, ShouldResemble, trash[0].Card)
+			So(trash2[0].Deleted.Equal(trash[0].Deleted), ShouldBeTrue)
+			So(nb2.Restore("c1"), ShouldBeNil)
+			So(nb2.MarshalBody(), ShouldEqual, before)
+
+			Convey("And left out once it is empty", func() {
+				So(nb2.Marshal(), ShouldNotContainSubstring, "trash:")
+			})
+		})
+
+		Convey("Badly formed trash is an error", func() {
+			_, err := notebook.Unmarshal("---\ntrash:\n- cards: \"\"\n---\n")
+			So(err.Error(), ShouldEqual, "malformed trash; each entry must hold exactly one card")
+			_, err = notebook.Unmarshal("---\ntrash:\n- cards: \"## Too deep\"\n---\n")
+			So(err.Error(), ShouldStartWith, "malformed trash: ")
+		})
+	})
+}
@@ -2,6 +2,8 @@ package ui
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/makyo/gotui"
 	tb "github.com/nsf/termbox-go"
@@ -78,6 +80,36 @@ func (t *tui) promoteAll(g *gotui.Gui, v *gotui.View) error {
 	return nil
 }
 
+func (t *tui) demote(g *gotui.Gui, v *gotui.View) error {
+	if t.modalOpen {
+		if t.editorOpen {
+			g.CurrentView().EditWrite('i')
+		}
+		return nil
+	}
+	maxX, _ := g.Size()
+	t.nb.Demote()
+	g.Update(func(gg *gotui.Gui) error {
+		return t.drawCards(gg, maxX)
+	})
+	return nil
+}
+
+func (t *tui) demoteAll(g *gotui.Gui, v *gotui.View) error {
+	if t.modalOpen {
+		if t.editorOpen {
+			g.CurrentView().EditWrite('I')
+		}
+		return nil
+	}
+	maxX, _ := g.Size()
+	t.nb.DemoteAll()
+	g.Update(func(gg *gotui.Gui) error {
+		return t.drawCards(gg, maxX)
+	})
+	return nil
+}
+
 func (t *tui) mergeDown(g *gotui.Gui, v *gotui.View) error {
 	if t.modalOpen {
 		if t.editorOpen {
@@ -138,16 +170,144 @@ func (t *tui) moveUp(g *gotui.Gui, v *gotui.View) error {
 	return nil
 }
 
+// wordCount describes a number of words.
+func wordCount(words int) string {
+	if words == 1 {
+		return "1 word"
+	}
+	return fmt.Sprintf("%d words", words)
+}
+
+// progressBar draws how far a number of words has come towards a target.
+func progressBar(words, target int) string {
+	percent := notebook.Progress(words, target)
+	filled := percent / 10
+	if filled > 10 {
+		filled = 10
+	}
+	return fmt.Sprintf("%s%s %d%%", strings.Repeat("█", filled), strings.Repeat("░", 10-filled), percent)
+}
+
+// cardCount describes the length of a card, along with that of its children if it has any, and its progress towards
+// its target.
+func cardCount(c notebook.Card) string {
+	result := wordCount(c.Counts.Words)
+	if len(c.Children) > 0 {
+		result = fmt.Sprintf("%d of %s", c.Counts.Words, wordCount(c.Total.Words))
+	}
+	if c.TotalTarget > 0 {
+		result += " ── " + progressBar(c.Total.Words, c.TotalTarget)
+	}
+	return result
+}
+
+func (t *tui) setTarget(g *gotui.Gui, v *gotui.View) error {
+	if t.modalOpen {
+		if t.editorOpen {
+			g.CurrentView().EditWrite('t')
+		}
+		return nil
+	}
+	current, err := t.nb.Lookup(t.nb.CurrentID())
+	if err != nil {
+		return nil
+	}
+	value := ""
+	if current.Target > 0 {
+		value = strconv.Itoa(current.Target)
+	}
+	return t.prompt(g, "Target words (blank for none)", value, nil, func(gg *gotui.Gui, value string) error {
+		words := 0
+		if value != "" {
+			if words, err = strconv.Atoi(value); err != nil {
+				t.createModal("Target", fmt.Sprintf("%s is not a number of words.", value))
+				return nil
+			}
+		}
+		if err := t.nb.SetTarget(words); err != nil {
+			t.createModal("Target", fmt.Sprintf("Unable to set target: %v", err))
+			return nil
+		}
+		maxX, _ := gg.Size()
+		return t.drawCards(gg, maxX)
+	})
+}
+
+// descendants returns the number of cards below a card.
+func descendants(c notebook.Card) int {
+	count := len(c.Children)
+	for _, child := range c.Children {
+		count += descendants(child)
+	}
+	return count
+}
+
+func (t *tui) deleteCard(g *gotui.Gui, v *gotui.View) error {
+	if t.modalOpen {
+		if t.editorOpen {
+			g.CurrentView().EditWrite('x')
+		}
+		return nil
+	}
+	maxX, _ := g.Size()
+	current, err := t.nb.Lookup(t.nb.CurrentID())
+	if err != nil {
+		return nil
+	}
+	remove := func(gg *gotui.Gui) error {
+		t.nb.Delete(true)
+		return t.drawCards(gg, maxX)
+	}
+	if count := descendants(current); count > 0 {
+		cards := "cards"
+		if count == 1 {
+			cards = "card"
+		}
+		return t.confirm(g, " Delete ", fmt.Sprintf("Deleting \"%s\" will also delete the %d %s below it. Are you sure?",
+			current.Title, count, cards), remove, confirmNoop)
+	}
+	g.Update(remove)
+	return nil
+}
+
+func (t *tui) undo(g *gotui.Gui, v *gotui.View) error {
+	if t.modalOpen {
+		if t.editorOpen {
+			g.CurrentView().EditWrite('z')
+		}
+		return nil
+	}
+	maxX, _ := g.Size()
+	t.nb.Undo()
+	g.Update(func(gg *gotui.Gui) error {
+		return t.drawCards(gg, maxX)
+	})
+	return nil
+}
+
+func (t *tui) redo(g *gotui.Gui, v *gotui.View) error {
+	if t.modalOpen {
+		if t.editorOpen {
+			g.CurrentView().EditWrite('Z')
+		}
+		return nil
+	}
+	maxX, _ := g.Size()
+	t.nb.Redo()
+	g.Update(func(gg *gotui.Gui) error {
+		return t.drawCards(gg, maxX)
+	})
+	return nil
+}
+
 func (t *tui) cycleUp(g *gotui.Gui, v *gotui.View) error {
 	if t.modalOpen {
 		if t.editorOpen {
 			g.CurrentView().MoveCursor(0, -1, false)
 		} else {
-			m, err := g.View("modal")
-			if err != nil {
-				return err
+			if m, err := g.View("modal"); err == nil {
+				t.scrollModalUp(g, m)
 			}
-			t.scrollModalUp(g, m)
 		}
 		return nil
 	}
@@ -164,11 +324,9 @@ func (t *tui) cycleDown(g *gotui.Gui, v *gotui.View) error {
 		if t.editorOpen {
 			g.CurrentView().MoveCursor(0, 1, false)
 		} else {
-			m, err := g.View("modal")
-			if err != nil {
-				return err
+			if m, err := g.View("modal"); err == nil {
+				t.scrollModalDown(g, m)
 			}
-			t.scrollModalDown(g, m)
 		}
 		return nil
 	}
@@ -244,15 +402,34 @@ func (t *tui) drawCard(currentCard notebook.Card, g *gotui.Gui, height, top, lef
 		v.Frame = true
 		v.Wrap = true
 		v.WordWrap = true
-		if !c.current {
+		colour := t.statusColour(c.card.Status)
+		if !c.current || !t.passesFilter(c.card) {
 			v.FrameFgColor = gotui.Attribute(tb.AttrDim | tb.ColorDarkGray)
 			v.TitleFgColor = gotui.Attribute(tb.AttrDim | tb.ColorDarkGray)
 			v.FgColor = gotui.Attribute(tb.AttrDim | tb.ColorDarkGray)
+			if colour != gotui.ColorDefault && t.passesFilter(c.card) {
+				v.FrameFgColor = colour | gotui.Attribute(tb.AttrDim)
+				v.TitleFgColor = colour | gotui.Attribute(tb.AttrDim)
+			}
 		} else {
+			v.FrameFgColor = colour | gotui.AttrBold
+			v.TitleFgColor = colour | gotui.AttrBold
+		}
+		if c.current {
 			t.currentDepth = depth
 			t.currentY = top
 		}
-		v.Title = fmt.Sprintf(" %s ", c.card.Title)
+		status := ""
+		if c.card.Status != "" {
+			status = c.card.Status + " ── "
+		}
+		for _, tag := range c.card.Tags {
+			status += "#" + tag + " "
+		}
+		if len(c.card.Tags) > 0 {
+			status += "── "
+		}
+		v.Title = fmt.Sprintf(" %s ── %s%s ", c.card.Title, status, cardCount(c.card))
 		fmt.Fprint(v, c.card.Body)
 
 		if _, err := g.SetViewOnBottom(name); err != nil {
@@ -260,6 +437,9 @@ func (t *tui) drawCard(currentCard notebook.Card, g *gotui.Gui, height, top, lef
 		}
 
 		for _, child := range currentCard.Children {
+			if !t.shown(child) {
+				continue
+			}
 			newTop, err := t.drawCard(child, g, height, top+t.colWidth/2+1, indent, depth+1)
 			if err != nil {
 				return -1, err
@@ -283,6 +463,9 @@ func (t *tui) drawCards(g *gotui.Gui, width int) error {
 	top := 0
 	_, maxY := g.Size()
 	for _, c := range tree {
+		if !t.shown(c) {
+			continue
+		}
 		newTop, err := t.drawCard(c, g, maxY, top, left, 0)
 		if err != nil {
 			return err
@@ -3,9 +3,12 @@ package ui
 import (
 	"fmt"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/makyo/gotui"
 	tb "github.com/nsf/termbox-go"
+
+	"github.com/makyo/mandelnote/notebook"
 )
 
 func (t *tui) edit(g *gotui.Gui, v *gotui.View) error {
@@ -14,13 +17,17 @@ func (t *tui) edit(g *gotui.Gui, v *gotui.View) error {
 			cv := g.CurrentView()
 			if cv.Name() == "editor" {
 				g.CurrentView().EditNewLine()
+			} else if t.form != nil {
+				return t.formEnter(g)
 			}
 		}
 		return nil
 	}
 	maxX, maxY := g.Size()
 	title, body := t.nb.GetCard()
-	if eb, err := g.SetView("editor", t.colWidth, t.colWidth/2, maxX-t.colWidth, maxY-t.colWidth/2); err != nil {
+	current, _ := t.nb.Lookup(t.nb.CurrentID())
+	propsLeft := maxX - t.colWidth - (maxX-2*t.colWidth)/3
+	if eb, err := g.SetView("editor", t.colWidth, t.colWidth/2, propsLeft-1, maxY-t.colWidth/2-3); err != nil {
 		if err != gotui.ErrUnknownView {
 			return err
 		}
@@ -44,14 +51,48 @@ func (t *tui) edit(g *gotui.Gui, v *gotui.View) error {
 
 		fmt.Fprint(et, title)
 	}
+	if pv, err := g.SetView("editorProperties", propsLeft, t.colWidth/2, maxX-t.colWidth, maxY-t.colWidth/2-3); err != nil {
+		if err != gotui.ErrUnknownView {
+			return err
+		}
+		pv.Title = " Properties "
+		pv.Editable = true
+		pv.FrameFgColor = gotui.Attribute(tb.AttrDim)
+		pv.FgColor = gotui.Attribute(tb.AttrDim)
+
+		fmt.Fprint(pv, current.Properties.String())
+	}
+	if tv, err := g.SetView("editorTags", t.colWidth, maxY-t.colWidth/2-2, maxX-t.colWidth, maxY-t.colWidth/2); err != nil {
+		if err != gotui.ErrUnknownView {
+			return err
+		}
+		tv.Title = " Tags "
+		tv.Editable = true
+		tv.FrameFgColor = gotui.Attribute(tb.AttrDim)
+		tv.FgColor = gotui.Attribute(tb.AttrDim)
+
+		fmt.Fprint(tv, strings.Join(current.Tags, " "))
+	}
 	g.Cursor = true
 	t.editorOpen = true
 	t.modalOpen = true
 	return nil
 }
 
-func (t *tui) closeEditor(g *gotui.Gui, v *gotui.View) error {
+// inEditor returns whether the card editor is open, as opposed to some other text input.
+func (t *tui) inEditor(g *gotui.Gui) bool {
 	if !t.editorOpen {
+		return false
+	}
+	_, err := g.View("editor")
+	return err == nil
+}
+
+func (t *tui) closeEditor(g *gotui.Gui, v *gotui.View) error {
+	if t.form != nil {
+		return t.submitForm(g)
+	}
+	if !t.inEditor(g) {
 		return nil
 	}
 	eb, err := g.View("editor")
@@ -62,19 +103,46 @@ func (t *tui) closeEditor(g *gotui.Gui, v *gotui.View) error {
 	if err != nil {
 		return err
 	}
-	t.nb.EditCard(strings.Join(et.BufferLines(), "\n"), strings.Join(eb.BufferLines(), "\n"))
+	edit := notebook.CardEdit{
+		Title: strings.Join(et.BufferLines(), "\n"),
+		Body:  strings.Join(eb.BufferLines(), "\n"),
+	}
 	if err = g.DeleteView("editor"); err != nil {
 		return err
 	}
 	if err = g.DeleteView("editorTitle"); err != nil {
 		return err
 	}
+
+	// The focus editor has no room for tags or properties, so those are only changed when their panes are open. Tags
+	// or properties which can't be read are left as they were, while the rest of the edit is kept.
+	var tagsErr, propsErr error
+	if tv, err := g.View("editorTags"); err == nil {
+		edit.Tags, tagsErr = notebook.ParseTags(strings.Join(tv.BufferLines(), " "))
+		if err = g.DeleteView("editorTags"); err != nil {
+			return err
+		}
+	}
+	if pv, err := g.View("editorProperties"); err == nil {
+		edit.Properties, propsErr = notebook.ParseProperties(strings.Join(pv.BufferLines(), "\n"))
+		if err = g.DeleteView("editorProperties"); err != nil {
+			return err
+		}
+	}
+	if err = t.nb.EditCardDetails(edit); err != nil {
+		return err
+	}
 	g.Cursor = false
 	t.modalOpen = false
 	t.editorOpen = false
 	t.focusMode = false
 	maxX, _ := g.Size()
 	t.drawCards(g, maxX)
+	if tagsErr != nil {
+		t.createModal("Tags not saved", tagsErr.Error())
+	} else if propsErr != nil {
+		t.createModal("Properties not saved", propsErr.Error())
+	}
 	return nil
 }
 
@@ -124,25 +192,87 @@ func (t *tui) focus(g *gotui.Gui, v *gotui.View) error {
 }
 
 func (t *tui) toggleEdit(g *gotui.Gui, v *gotui.View) error {
-	if !t.editorOpen {
+	if t.form != nil {
+		return t.nextFormField(g)
+	}
+	if !t.inEditor(g) {
 		return nil
 	}
+	if v.Name() == "editorTags" {
+		text := strings.Join(v.BufferLines(), " ")
+		if completed := completeWord(text, t.nb.Tags(), ""); completed != text {
+			v.Clear()
+			fmt.Fprint(v, completed)
+			return v.SetCursor(len([]rune(completed)), 0)
+		}
+	}
+
+	// Move on to the next pane of the editor, skipping those which aren't open.
+	panes := []string{"editorTitle", "editor", "editorProperties", "editorTags"}
+	next := 0
+	for i, pane := range panes {
+		if pane == v.Name() {
+			next = (i + 1) % len(panes)
+		}
+	}
+	for {
+		if _, err := g.View(panes[next]); err == nil {
+			break
+		}
+		next = (next + 1) % len(panes)
+	}
 	v.FrameFgColor = gotui.Attribute(tb.AttrDim)
 	v.FgColor = gotui.Attribute(tb.AttrDim)
-	if v.Name() == "editor" {
-		v, err := g.SetCurrentView("editorTitle")
-		if err != nil {
-			return err
+	nv, err := g.SetCurrentView(panes[next])
+	if err != nil {
+		return err
+	}
+	nv.FrameFgColor = gotui.ColorDefault
+	nv.FgColor = gotui.ColorDefault
+	return nil
+}
+
+// cursorOffset returns the byte offset into the editor's buffer of the cursor, accounting for wrapped lines.
+func cursorOffset(v *gotui.View) int {
+	cx, cy := v.Cursor()
+	_, oy := v.Origin()
+	bufferLines := v.BufferLines()
+	viewLines := v.ViewBufferLines()
+	line, col := 0, 0
+	for i := 0; i < cy+oy && i < len(viewLines) && line < len(bufferLines); i++ {
+		col += utf8.RuneCountInString(viewLines[i])
+		if col >= utf8.RuneCountInString(bufferLines[line]) {
+			line++
+			col = 0
 		}
-		v.FrameFgColor = gotui.ColorDefault
-		v.FgColor = gotui.ColorDefault
-	} else {
-		v, err := g.SetCurrentView("editor")
-		if err != nil {
-			return err
+	}
+	offset := 0
+	for i := 0; i < line && i < len(bufferLines); i++ {
+		offset += len(bufferLines[i]) + 1
+	}
+	if line < len(bufferLines) {
+		runes := []rune(bufferLines[line])
+		col += cx
+		if col > len(runes) {
+			col = len(runes)
 		}
-		v.FrameFgColor = gotui.ColorDefault
-		v.FgColor = gotui.ColorDefault
+		offset += len(string(runes[:col]))
+	}
+	return offset
+}
+
+func (t *tui) split(g *gotui.Gui, v *gotui.View) error {
+	if !t.editorOpen {
+		return nil
 	}
+	offset := cursorOffset(v)
+	if err := t.closeEditor(g, v); err != nil {
+		return err
+	}
+	maxX, _ := g.Size()
+	t.nb.Split(offset, "New Card", false)
+	g.Update(func(gg *gotui.Gui) error {
+		return t.drawCards(gg, maxX)
+	})
 	return nil
 }
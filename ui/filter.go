@@ -0,0 +1,115 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/makyo/gotui"
+
+	"github.com/makyo/mandelnote/notebook"
+)
+
+// setFilter limits the cards drawn to those matching the filter, along with those needed to reach them. A nil filter
+// shows every card.
+func (t *tui) setFilter(description string, matches func(notebook.Card) bool) {
+	t.filterDescription = description
+	t.filter = matches
+}
+
+// passesFilter returns whether the card matches the current filter, if there is one.
+func (t *tui) passesFilter(c notebook.Card) bool {
+	return t.filter == nil || t.filter(c)
+}
+
+// shown returns whether the card should be drawn: it must match the filter, be the current card, or have a descendant
+// which should be drawn.
+func (t *tui) shown(c notebook.Card) bool {
+	if t.passesFilter(c) || c.Current {
+		return true
+	}
+	for _, child := range c.Children {
+		if t.shown(child) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *tui) filterTags(g *gotui.Gui, v *gotui.View) error {
+	if t.modalOpen {
+		if t.editorOpen {
+			g.CurrentView().EditWrite('#')
+		}
+		return nil
+	}
+	complete := func(text string) string {
+		return completeWord(text, t.nb.Tags(), "!")
+	}
+	return t.prompt(g, "Show only tags matching (such as ada !harbor | bob, or blank for all)", t.tagFilter, complete,
+		func(gg *gotui.Gui, query string) error {
+			if query == "" {
+				t.tagFilter = ""
+				t.queryFilter = ""
+				t.setFilter("", nil)
+			} else {
+				expr, err := notebook.ParseTagExpression(query)
+				if err != nil {
+					t.createModal("Tags", fmt.Sprintf("Unable to filter by %s: %v", query, err))
+					return nil
+				}
+				t.tagFilter = query
+				t.queryFilter = ""
+				t.setFilter("tags "+query, func(c notebook.Card) bool {
+					return expr.Matches(c.Tags)
+				})
+			}
+			maxX, _ := gg.Size()
+			if err := t.setTitle(gg); err != nil {
+				return err
+			}
+			return t.drawCards(gg, maxX)
+		})
+}
+
+// queryFields are the fields of a query, offered when completing one.
+var queryFields = []string{"depth:", "position:", "children:", "haschildren:", "title:", "body:", "tag:", "status:",
+	"prop."}
+
+func (t *tui) filterQuery(g *gotui.Gui, v *gotui.View) error {
+	if t.modalOpen {
+		if t.editorOpen {
+			g.CurrentView().EditWrite('Q')
+		}
+		return nil
+	}
+	complete := func(text string) string {
+		candidates := append([]string{}, queryFields...)
+		for _, tag := range t.nb.Tags() {
+			candidates = append(candidates, "tag:"+tag)
+		}
+		for _, status := range t.nb.Statuses() {
+			candidates = append(candidates, "status:"+status)
+		}
+		return completeWord(text, candidates, "!")
+	}
+	title := "Show only cards matching (such as depth:2 haschildren:no title:/^Scene/ \"harbor\", or blank for all)"
+	return t.prompt(g, title, t.queryFilter, complete, func(gg *gotui.Gui, query string) error {
+		t.tagFilter = ""
+		if query == "" {
+			t.queryFilter = ""
+			t.setFilter("", nil)
+		} else {
+			q, err := notebook.ParseQuery(query)
+			if err != nil {
+				t.createModal("Query", fmt.Sprintf("Unable to filter by %s: %v", query, err))
+				return nil
+			}
+			t.queryFilter = query
+			t.setFilter(query, q.Matches)
+		}
+		maxX, _ := gg.Size()
+		if err := t.setTitle(gg); err != nil {
+			return err
+		}
+		return t.drawCards(gg, maxX)
+	})
+}
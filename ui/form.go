@@ -0,0 +1,168 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/makyo/ansigo"
+	"github.com/makyo/gotui"
+	tb "github.com/nsf/termbox-go"
+)
+
+// formField is a single labelled input in a form.
+type formField struct {
+	label     string
+	value     string
+	multiline bool
+}
+
+// form holds the state of an open form.
+type form struct {
+	fields  []formField
+	current int
+	done    func(*gotui.Gui, []string) error
+}
+
+func formFieldName(i int) string {
+	return fmt.Sprintf("formField%d", i)
+}
+
+// height returns the number of lines the field takes up, including its frame.
+func (f formField) height() int {
+	if f.multiline {
+		return 7
+	}
+	return 3
+}
+
+// showForm opens a modal with an input for each field, followed by any extra information. Tab moves between fields,
+// ctrl+W accepts the form, calling done with the value of each field in order, and esc cancels it.
+func (t *tui) showForm(g *gotui.Gui, title string, fields []formField, info string, done func(*gotui.Gui, []string) error) error {
+	if t.modalOpen {
+		return nil
+	}
+	maxX, maxY := g.Size()
+	left, right := t.colWidth*2, maxX-t.colWidth*2
+	if v, err := g.SetView("form", left, 2, right, maxY-2); err != nil {
+		if err != gotui.ErrUnknownView {
+			return err
+		}
+		v.Frame = true
+		v.FrameFgColor = gotui.ColorCyan | gotui.AttrBold
+		v.TitleFgColor = gotui.AttrBold
+		v.Title = fmt.Sprintf(" %s ", title)
+		v.Wrap = true
+		v.WordWrap = true
+
+		// The fields are drawn over the top of the form, so the information is pushed down below them.
+		top := 0
+		for _, f := range fields {
+			top += f.height()
+		}
+		fmt.Fprint(v, strings.Repeat("\n", top+1), info)
+	}
+	top := 3
+	for i, f := range fields {
+		if v, err := g.SetView(formFieldName(i), left+2, top, right-2, top+f.height()-1); err != nil {
+			if err != gotui.ErrUnknownView {
+				return err
+			}
+			v.Title = fmt.Sprintf(" %s ", f.label)
+			v.Editable = true
+			v.Wrap = f.multiline
+			v.WordWrap = f.multiline
+			v.FrameFgColor = gotui.Attribute(tb.AttrDim)
+			fmt.Fprint(v, f.value)
+		}
+		if _, err := g.SetViewOnTop(formFieldName(i)); err != nil {
+			return err
+		}
+		top += f.height()
+	}
+	helpMsg := " Next: tab | Done: ctrl+W | Cancel: esc "
+	if v, err := g.SetView("formHelp", right-len(helpMsg)-3, maxY-3, right-1, maxY-1); err != nil {
+		if err != gotui.ErrUnknownView {
+			return err
+		}
+		v.Frame = false
+		fmt.Fprint(v, ansigo.MaybeApplyWithReset("bold", helpMsg))
+	}
+	g.Cursor = true
+	t.modalOpen = true
+	t.editorOpen = true
+	t.form = &form{
+		fields:  fields,
+		current: len(fields) - 1,
+		done:    done,
+	}
+	return t.nextFormField(g)
+}
+
+// nextFormField moves the cursor to the next field in the form, wrapping around to the first.
+func (t *tui) nextFormField(g *gotui.Gui) error {
+	if v, err := g.View(formFieldName(t.form.current)); err == nil {
+		v.FrameFgColor = gotui.Attribute(tb.AttrDim)
+	}
+	t.form.current = (t.form.current + 1) % len(t.form.fields)
+	v, err := g.SetCurrentView(formFieldName(t.form.current))
+	if err != nil {
+		return err
+	}
+	v.FrameFgColor = gotui.ColorDefault
+	return nil
+}
+
+// formEnter moves on to the next field, or starts a new line in fields which allow them.
+func (t *tui) formEnter(g *gotui.Gui) error {
+	if t.form.fields[t.form.current].multiline {
+		g.CurrentView().EditNewLine()
+		return nil
+	}
+	return t.nextFormField(g)
+}
+
+func (t *tui) closeForm(g *gotui.Gui) error {
+	for i := range t.form.fields {
+		if err := g.DeleteView(formFieldName(i)); err != nil {
+			return err
+		}
+	}
+	if err := g.DeleteView("form"); err != nil {
+		return err
+	}
+	if err := g.DeleteView("formHelp"); err != nil {
+		return err
+	}
+	g.Cursor = false
+	t.modalOpen = false
+	t.editorOpen = false
+	t.form = nil
+	return nil
+}
+
+func (t *tui) submitForm(g *gotui.Gui) error {
+	values := make([]string, len(t.form.fields))
+	for i, f := range t.form.fields {
+		v, err := g.View(formFieldName(i))
+		if err != nil {
+			return err
+		}
+		sep := ""
+		if f.multiline {
+			sep = "\n"
+		}
+		values[i] = strings.TrimSpace(strings.Join(v.BufferLines(), sep))
+	}
+	done := t.form.done
+	if err := t.closeForm(g); err != nil {
+		return err
+	}
+	return done(g, values)
+}
+
+func (t *tui) cancelForm(g *gotui.Gui, v *gotui.View) error {
+	if t.form == nil {
+		return nil
+	}
+	return t.closeForm(g)
+}
@@ -0,0 +1,174 @@
+package ui
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/makyo/ansigo"
+	"github.com/makyo/gotui"
+)
+
+// prompt opens a single line input asking for a value. When enter is hit, the input is closed and done is called with
+// what was entered. If complete is not nil, tab replaces the value with its result.
+func (t *tui) prompt(g *gotui.Gui, title, value string, complete func(string) string, done func(*gotui.Gui, string) error) error {
+	if t.modalOpen {
+		return nil
+	}
+	maxX, maxY := g.Size()
+	if v, err := g.SetView("input", t.colWidth*2, maxY/2-1, maxX-t.colWidth*2, maxY/2+1); err != nil {
+		if err != gotui.ErrUnknownView {
+			return err
+		}
+		v.Frame = true
+		v.FrameFgColor = gotui.ColorCyan | gotui.AttrBold
+		v.TitleFgColor = gotui.AttrBold
+		v.Title = fmt.Sprintf(" %s ", title)
+		v.Editable = true
+		fmt.Fprint(v, value)
+		v.SetCursor(len([]rune(value)), 0)
+	}
+	helpMsg := " Cancel: esc "
+	if complete != nil {
+		helpMsg = " Complete: tab | Cancel: esc "
+	}
+	if v, err := g.SetView("inputHelp", maxX-t.colWidth*2-len(helpMsg)-2, maxY/2, maxX-t.colWidth*2-1, maxY/2+2); err != nil {
+		if err != gotui.ErrUnknownView {
+			return err
+		}
+		v.Frame = false
+		fmt.Fprint(v, ansigo.MaybeApplyWithReset("bold", helpMsg))
+	}
+	if _, err := g.SetViewOnTop("input"); err != nil {
+		return err
+	}
+	if _, err := g.SetCurrentView("input"); err != nil {
+		return err
+	}
+	g.Cursor = true
+	t.modalOpen = true
+	t.editorOpen = true
+	t.inputCompleteFn = complete
+	t.inputDoneFn = done
+	return nil
+}
+
+// inputValue returns the text entered into the input.
+func inputValue(v *gotui.View) string {
+	return strings.TrimSpace(strings.Join(v.BufferLines(), ""))
+}
+
+func (t *tui) closeInput(g *gotui.Gui) error {
+	if err := g.DeleteView("input"); err != nil {
+		return err
+	}
+	if err := g.DeleteView("inputHelp"); err != nil {
+		return err
+	}
+	g.Cursor = false
+	t.modalOpen = false
+	t.editorOpen = false
+	t.inputCompleteFn = nil
+	t.inputDoneFn = nil
+	return nil
+}
+
+func (t *tui) submitInput(g *gotui.Gui, v *gotui.View) error {
+	value := inputValue(v)
+	done := t.inputDoneFn
+	if err := t.closeInput(g); err != nil {
+		return err
+	}
+	if done == nil {
+		return nil
+	}
+	return done(g, value)
+}
+
+func (t *tui) cancelInput(g *gotui.Gui, v *gotui.View) error {
+	return t.closeInput(g)
+}
+
+func (t *tui) completeInput(g *gotui.Gui, v *gotui.View) error {
+	if t.inputCompleteFn == nil {
+		return nil
+	}
+	value := t.inputCompleteFn(inputValue(v))
+	v.Clear()
+	fmt.Fprint(v, value)
+	v.SetOrigin(0, 0)
+	return v.SetCursor(len([]rune(value)), 0)
+}
+
+// completePath completes a partial path as far as the files matching it agree. Directories are completed with a
+// trailing separator so that completion may carry on into them.
+func completePath(partial string) string {
+	dir, base := filepath.Split(partial)
+	lookIn := dir
+	if lookIn == "" {
+		lookIn = "."
+	}
+	files, err := ioutil.ReadDir(lookIn)
+	if err != nil {
+		return partial
+	}
+	matches := []os.FileInfo{}
+	for _, f := range files {
+		if strings.HasPrefix(f.Name(), base) && (strings.HasPrefix(base, ".") || !strings.HasPrefix(f.Name(), ".")) {
+			matches = append(matches, f)
+		}
+	}
+	if len(matches) == 0 {
+		return partial
+	}
+	if len(matches) == 1 {
+		if matches[0].IsDir() {
+			return dir + matches[0].Name() + string(filepath.Separator)
+		}
+		return dir + matches[0].Name()
+	}
+	names := []string{}
+	for _, f := range matches {
+		names = append(names, f.Name())
+	}
+	return dir + commonPrefix(names)
+}
+
+// commonPrefix returns the longest prefix shared by all of the candidates.
+func commonPrefix(candidates []string) string {
+	if len(candidates) == 0 {
+		return ""
+	}
+	common := candidates[0]
+	for _, candidate := range candidates[1:] {
+		for !strings.HasPrefix(candidate, common) {
+			_, size := utf8.DecodeLastRuneInString(common)
+			common = common[:len(common)-size]
+		}
+	}
+	return common
+}
+
+// completeWord completes the last word of some text as far as the candidates starting with it agree, keeping any of
+// the given prefix characters, such as `!`, in front of it.
+func completeWord(text string, candidates []string, prefixes string) string {
+	start := strings.LastIndexAny(text, " \t") + 1
+	word := strings.TrimLeft(text[start:], prefixes)
+	start = len(text) - len(word)
+	if word == "" {
+		return text
+	}
+	matches := []string{}
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, word) {
+			matches = append(matches, candidate)
+		}
+	}
+	if len(matches) == 0 {
+		return text
+	}
+	return text[:start] + commonPrefix(matches)
+}
@@ -0,0 +1,90 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/makyo/ansigo"
+	"github.com/makyo/gotui"
+)
+
+// showList opens a modal listing one item per line, with the cursor on the first. The view is given the name passed
+// so that each list may have its own keybindings.
+func (t *tui) showList(g *gotui.Gui, name, title string, items []string, helpMsg string) error {
+	if t.modalOpen {
+		return nil
+	}
+	maxX, maxY := g.Size()
+	if v, err := g.SetView(name, 3, 3, maxX-4, maxY-4); err != nil {
+		if err != gotui.ErrUnknownView {
+			return err
+		}
+		v.Frame = true
+		v.FrameFgColor = gotui.ColorCyan | gotui.AttrBold
+		v.TitleFgColor = gotui.AttrBold
+		v.Title = fmt.Sprintf(" %s ", title)
+		v.Highlight = true
+		v.SelBgColor = gotui.ColorCyan
+		v.SelFgColor = gotui.ColorBlack
+		fmt.Fprint(v, strings.Join(items, "\n"))
+	}
+	if v, err := g.SetView(name+"Help", maxX-3-len(helpMsg), maxY-5, maxX-6, maxY-3); err != nil {
+		if err != gotui.ErrUnknownView {
+			return err
+		}
+		v.Frame = false
+		fmt.Fprint(v, ansigo.MaybeApplyWithReset("bold", helpMsg))
+	}
+	if _, err := g.SetViewOnTop(name); err != nil {
+		return err
+	}
+	if _, err := g.SetCurrentView(name); err != nil {
+		return err
+	}
+	t.modalOpen = true
+	return nil
+}
+
+// closeList closes the list in the given view.
+func (t *tui) closeList(g *gotui.Gui, v *gotui.View) error {
+	if err := g.DeleteView(v.Name()); err != nil {
+		return err
+	}
+	if err := g.DeleteView(v.Name() + "Help"); err != nil {
+		return err
+	}
+	t.modalOpen = false
+	return nil
+}
+
+// listSelected returns the index of the item under the cursor.
+func listSelected(v *gotui.View) int {
+	_, cy := v.Cursor()
+	_, oy := v.Origin()
+	return cy + oy
+}
+
+func (t *tui) listUp(g *gotui.Gui, v *gotui.View) error {
+	_, cy := v.Cursor()
+	_, oy := v.Origin()
+	if cy > 0 {
+		return v.SetCursor(0, cy-1)
+	}
+	if oy > 0 {
+		return v.SetOrigin(0, oy-1)
+	}
+	return nil
+}
+
+func (t *tui) listDown(g *gotui.Gui, v *gotui.View) error {
+	_, cy := v.Cursor()
+	_, oy := v.Origin()
+	_, maxY := v.Size()
+	if listSelected(v) >= len(v.BufferLines())-1 {
+		return nil
+	}
+	if cy < maxY-1 {
+		return v.SetCursor(0, cy+1)
+	}
+	return v.SetOrigin(0, oy+1)
+}
@@ -2,6 +2,7 @@ package ui
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/makyo/ansigo"
 	"github.com/makyo/gotui"
@@ -20,19 +21,34 @@ var (
 		%s - edit notebook metadata
 		%s - save/save as...
 		%s - quit
+		%s - search
+		%s - find and replace
 
 		%s - new card
 		%s - new child card
 		%s - edit card
 		%s - focus edit
 		%s - stop editing
-		%s - toggle between editing card title and card body
+		%s - move between card title, body, properties and tags, completing tags
+		%s - split card at the cursor into a new card
 		%s - promote card
 		%s - promote all cards at this level
+		%s - demote card under the previous card
+		%s - demote this and all following cards
 		%s - merge card up
 		%s - merge card down
 		%s - move card up
 		%s - move card down
+		%s - set word count target
+		%s - advance card status
+		%s - move card status back
+		%s - show only cards with a status
+		%s - show only cards with tags
+		%s - show only cards matching a query
+		%s - delete card
+		%s - show deleted cards
+		%s - undo
+		%s - redo
 
 		%s - move to next card
 		%s - move to previous card
@@ -54,6 +70,8 @@ var (
 		ansigo.MaybeApplyWithReset("cyan", "e       "),
 		ansigo.MaybeApplyWithReset("cyan", "s/ctrl+S"),
 		ansigo.MaybeApplyWithReset("cyan", "ctrl+Q  "),
+		ansigo.MaybeApplyWithReset("cyan", "/       "),
+		ansigo.MaybeApplyWithReset("cyan", "R       "),
 
 		ansigo.MaybeApplyWithReset("cyan", "n      "),
 		ansigo.MaybeApplyWithReset("cyan", "N      "),
@@ -61,12 +79,25 @@ var (
 		ansigo.MaybeApplyWithReset("cyan", "f      "),
 		ansigo.MaybeApplyWithReset("cyan", "ctlr+W "),
 		ansigo.MaybeApplyWithReset("cyan", "tab    "),
+		ansigo.MaybeApplyWithReset("cyan", "ctrl+X "),
 		ansigo.MaybeApplyWithReset("cyan", "p      "),
 		ansigo.MaybeApplyWithReset("cyan", "P      "),
+		ansigo.MaybeApplyWithReset("cyan", "i      "),
+		ansigo.MaybeApplyWithReset("cyan", "I      "),
 		ansigo.MaybeApplyWithReset("cyan", "m      "),
 		ansigo.MaybeApplyWithReset("cyan", "M      "),
 		ansigo.MaybeApplyWithReset("cyan", "u      "),
 		ansigo.MaybeApplyWithReset("cyan", "d      "),
+		ansigo.MaybeApplyWithReset("cyan", "t      "),
+		ansigo.MaybeApplyWithReset("cyan", "a      "),
+		ansigo.MaybeApplyWithReset("cyan", "A      "),
+		ansigo.MaybeApplyWithReset("cyan", "F      "),
+		ansigo.MaybeApplyWithReset("cyan", "#      "),
+		ansigo.MaybeApplyWithReset("cyan", "Q      "),
+		ansigo.MaybeApplyWithReset("cyan", "x      "),
+		ansigo.MaybeApplyWithReset("cyan", "T      "),
+		ansigo.MaybeApplyWithReset("cyan", "z      "),
+		ansigo.MaybeApplyWithReset("cyan", "Z      "),
 
 		ansigo.MaybeApplyWithReset("cyan", "down "),
 		ansigo.MaybeApplyWithReset("cyan", "up   "),
@@ -162,6 +193,38 @@ func (t *tui) createModal(title, content string) {
 	})
 }
 
+// confirm asks a yes or no question, calling yes or no with the answer.
+func (t *tui) confirm(g *gotui.Gui, title, msg string, yes, no func(*gotui.Gui) error) error {
+	maxX, maxY := g.Size()
+
+	// The box grows upwards to fit messages of more than one line, keeping the actions in the same place.
+	extra := strings.Count(msg, "\n")
+	if v, err := g.SetView("confirm", t.colWidth*2, maxY/2-2-extra, maxX-t.colWidth*2, maxY/2+2); err != nil {
+		if err != gotui.ErrUnknownView {
+			return err
+		}
+		t.modalOpen = true
+		v.Frame = true
+		v.FrameFgColor = gotui.ColorCyan | gotui.AttrBold
+		v.TitleFgColor = gotui.AttrBold
+		v.Title = title
+		v.Wrap = true
+		v.WordWrap = true
+		fmt.Fprint(v, msg)
+	}
+	if v, err := g.SetView("confirmActions", maxX-t.colWidth*2-20, maxY/2+1, maxX-t.colWidth*2-2, maxY/2+3); err != nil {
+		if err != gotui.ErrUnknownView {
+			return err
+		}
+		v.Frame = false
+		fmt.Fprint(v, confirmMsg)
+	}
+	g.SetCurrentView("confirm")
+	t.confirmYesFn = yes
+	t.confirmNoFn = no
+	return nil
+}
+
 func confirmNoop(g *gotui.Gui) error {
 	return nil
 }
@@ -176,12 +239,11 @@ func (t *tui) confirmYes(g *gotui.Gui, v *gotui.View) error {
 	if err := g.DeleteView("confirmActions"); err != nil {
 		return err
 	}
-	if err := t.confirmYesFn(g); err != nil {
-		return err
-	}
+	t.modalOpen = false
+	fn := t.confirmYesFn
 	t.confirmYesFn = confirmNoop
 	t.confirmNoFn = confirmNoop
-	return nil
+	return fn(g)
 }
 
 func (t *tui) confirmNo(g *gotui.Gui, v *gotui.View) error {
@@ -194,10 +256,9 @@ func (t *tui) confirmNo(g *gotui.Gui, v *gotui.View) error {
 	if err := g.DeleteView("confirmActions"); err != nil {
 		return err
 	}
-	if err := t.confirmNoFn(g); err != nil {
-		return err
-	}
+	t.modalOpen = false
+	fn := t.confirmNoFn
 	t.confirmYesFn = confirmNoop
 	t.confirmNoFn = confirmNoop
-	return nil
+	return fn(g)
 }
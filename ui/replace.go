@@ -0,0 +1,114 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/makyo/ansigo"
+	"github.com/makyo/gotui"
+
+	"github.com/makyo/mandelnote/notebook"
+)
+
+// scopes maps what may be typed into the scope field of the replace form to the scope it means.
+var scopes = map[string]notebook.Scope{
+	"card":     notebook.CurrentCard,
+	"subtree":  notebook.Subtree,
+	"notebook": notebook.WholeNotebook,
+}
+
+// describeChange shows the text around a change before and after it is made.
+func describeChange(change notebook.Change) string {
+	return fmt.Sprintf("%s\n%s", ansigo.MaybeApplyWithReset("red", whitespace.ReplaceAllString(change.Before, " ")),
+		ansigo.MaybeApplyWithReset("green", whitespace.ReplaceAllString(change.After, " ")))
+}
+
+func (t *tui) replace(g *gotui.Gui, v *gotui.View) error {
+	if t.modalOpen {
+		if t.editorOpen {
+			g.CurrentView().EditWrite('R')
+		}
+		return nil
+	}
+	fields := []formField{
+		{label: "Find"},
+		{label: "Replace with"},
+		{label: "Scope (card, subtree or notebook)", value: "notebook"},
+		{label: "Confirm each change (yes or no)", value: "yes"},
+	}
+	info := "Searches are case-insensitive unless they contain an uppercase letter. Wrap a search in slashes, such as " +
+		"/the (\\w+)/, to use a regular expression, in which case the replacement may use $1 and so on."
+	return t.showForm(g, "Replace", fields, info, func(gg *gotui.Gui, values []string) error {
+		if values[0] == "" {
+			return nil
+		}
+		scope, ok := scopes[strings.ToLower(values[2])]
+		if !ok {
+			t.createModal("Replace", fmt.Sprintf("Unknown scope %s; use card, subtree or notebook.", values[2]))
+			return nil
+		}
+		pattern, searchOpts := parseQuery(values[0])
+		changes, err := t.nb.Replace(pattern, values[1], scope, notebook.ReplaceOptions{
+			Mode:   searchOpts.Mode,
+			DryRun: true,
+		})
+		if err != nil {
+			t.createModal("Replace", fmt.Sprintf("Unable to replace %s: %v", values[0], err))
+			return nil
+		}
+		if len(changes) == 0 {
+			t.createModal("Replace", fmt.Sprintf("No matches for %s.", values[0]))
+			return nil
+		}
+		if strings.HasPrefix(strings.ToLower(values[3]), "y") {
+			return t.confirmChanges(gg, changes, 0, []notebook.Change{})
+		}
+		return t.applyChanges(gg, changes)
+	})
+}
+
+// confirmChanges asks about each change in turn, starting at the given one, then makes those which were accepted.
+func (t *tui) confirmChanges(g *gotui.Gui, changes []notebook.Change, i int, accepted []notebook.Change) error {
+	if i == len(changes) {
+		return t.applyChanges(g, accepted)
+	}
+	next := func(accept bool) func(*gotui.Gui) error {
+		return func(gg *gotui.Gui) error {
+			if accept {
+				accepted = append(accepted, changes[i])
+			}
+			return t.confirmChanges(gg, changes, i+1, accepted)
+		}
+	}
+	title := fmt.Sprintf(" Replace %d of %d ", i+1, len(changes))
+	return t.confirm(g, title, describeChange(changes[i]), next(true), next(false))
+}
+
+// applyChanges makes the changes and lists them.
+func (t *tui) applyChanges(g *gotui.Gui, changes []notebook.Change) error {
+	if len(changes) == 0 {
+		t.createModal("Replace", "Nothing was changed.")
+		return nil
+	}
+	if err := t.nb.ApplyChanges(changes); err != nil {
+		t.createModal("Replace", fmt.Sprintf("Unable to replace: %v", err))
+		return nil
+	}
+	maxX, _ := g.Size()
+	if err := t.drawCards(g, maxX); err != nil {
+		return err
+	}
+	report := "Made 1 change.\n"
+	if len(changes) > 1 {
+		report = fmt.Sprintf("Made %d changes.\n", len(changes))
+	}
+	for _, change := range changes {
+		c, err := t.nb.Lookup(change.ID)
+		if err != nil {
+			continue
+		}
+		report += fmt.Sprintf("\n%s\n%s\n", ansigo.MaybeApplyWithReset("underline", c.Title), describeChange(change))
+	}
+	t.createModal("Replace", report)
+	return nil
+}
@@ -0,0 +1,113 @@
+package ui
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/makyo/ansigo"
+	"github.com/makyo/gotui"
+
+	"github.com/makyo/mandelnote/notebook"
+)
+
+// snippetContext is the number of bytes either side of a match shown in search results.
+const snippetContext = 30
+
+var whitespace = regexp.MustCompile(`\s+`)
+
+// snippet returns the text around a match on a single line, with the match itself highlighted.
+func snippet(text string, start, end int) string {
+	from := start - snippetContext
+	if from < 0 {
+		from = 0
+	}
+	to := end + snippetContext
+	if to > len(text) {
+		to = len(text)
+	}
+	for from > 0 && !utf8.RuneStart(text[from]) {
+		from--
+	}
+	for to < len(text) && !utf8.RuneStart(text[to]) {
+		to++
+	}
+	flatten := func(s string) string {
+		return whitespace.ReplaceAllString(s, " ")
+	}
+	result := flatten(text[from:start]) + ansigo.MaybeApplyWithReset("bold+underline", flatten(text[start:end])) + flatten(text[end:to])
+	if from > 0 {
+		result = "…" + result
+	}
+	if to < len(text) {
+		result += "…"
+	}
+	return strings.TrimSpace(result)
+}
+
+// parseQuery works out how to search for what was typed into the search prompt. Queries between slashes are regular
+// expressions, and others are case-insensitive unless they contain an uppercase letter.
+func parseQuery(query string) (string, notebook.SearchOptions) {
+	if len(query) > 1 && strings.HasPrefix(query, "/") && strings.HasSuffix(query, "/") {
+		return query[1 : len(query)-1], notebook.SearchOptions{Mode: notebook.Regexp}
+	}
+	for _, r := range query {
+		if unicode.IsUpper(r) {
+			return query, notebook.SearchOptions{Mode: notebook.Plain}
+		}
+	}
+	return query, notebook.SearchOptions{Mode: notebook.CaseInsensitive}
+}
+
+func (t *tui) search(g *gotui.Gui, v *gotui.View) error {
+	if t.modalOpen {
+		if t.editorOpen {
+			g.CurrentView().EditWrite('/')
+		}
+		return nil
+	}
+	return t.prompt(g, "Search", "", nil, func(gg *gotui.Gui, query string) error {
+		if query == "" {
+			return nil
+		}
+		matches, err := t.nb.Search(parseQuery(query))
+		if err != nil {
+			t.createModal("Search", fmt.Sprintf("Unable to search for %s: %v", query, err))
+			return nil
+		}
+		if len(matches) == 0 {
+			t.createModal("Search", fmt.Sprintf("No matches for %s.", query))
+			return nil
+		}
+		items := []string{}
+		for _, m := range matches {
+			c, err := t.nb.LookupPath(m.Path)
+			if err != nil {
+				continue
+			}
+			if m.InTitle {
+				items = append(items, snippet(c.Title, m.Start, m.End))
+			} else {
+				items = append(items, fmt.Sprintf("%s: %s", c.Title, snippet(c.Body, m.Start, m.End)))
+			}
+		}
+		t.matches = matches
+		return t.showList(gg, "results", fmt.Sprintf("%d matches for %s", len(matches), query), items, " Go to card: enter | Close: Q ")
+	})
+}
+
+func (t *tui) gotoResult(g *gotui.Gui, v *gotui.View) error {
+	selected := listSelected(v)
+	if err := t.closeList(g, v); err != nil {
+		return err
+	}
+	if selected >= len(t.matches) {
+		return nil
+	}
+	maxX, _ := g.Size()
+	t.nb.SelectPath(t.matches[selected].Path)
+	t.matches = nil
+	return t.drawCards(g, maxX)
+}
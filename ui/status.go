@@ -0,0 +1,98 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/makyo/gotui"
+
+	"github.com/makyo/mandelnote/notebook"
+)
+
+// statusColours are the colours given to statuses from first to last.
+var statusColours = []gotui.Attribute{
+	gotui.ColorMagenta,
+	gotui.ColorBlue,
+	gotui.ColorYellow,
+	gotui.ColorCyan,
+	gotui.ColorGreen,
+}
+
+// statusColour returns the colour of a status, spreading the notebook's statuses across the colours available.
+func (t *tui) statusColour(status string) gotui.Attribute {
+	index := t.nb.StatusIndex(status)
+	if index == -1 {
+		return gotui.ColorDefault
+	}
+	statuses := len(t.nb.Statuses())
+	if statuses <= len(statusColours) {
+		return statusColours[index]
+	}
+	return statusColours[index*len(statusColours)/statuses]
+}
+
+func (t *tui) advanceStatus(g *gotui.Gui, v *gotui.View) error {
+	if t.modalOpen {
+		if t.editorOpen {
+			g.CurrentView().EditWrite('a')
+		}
+		return nil
+	}
+	maxX, _ := g.Size()
+	t.nb.AdvanceStatus(1)
+	g.Update(func(gg *gotui.Gui) error {
+		return t.drawCards(gg, maxX)
+	})
+	return nil
+}
+
+func (t *tui) retreatStatus(g *gotui.Gui, v *gotui.View) error {
+	if t.modalOpen {
+		if t.editorOpen {
+			g.CurrentView().EditWrite('A')
+		}
+		return nil
+	}
+	maxX, _ := g.Size()
+	t.nb.AdvanceStatus(-1)
+	g.Update(func(gg *gotui.Gui) error {
+		return t.drawCards(gg, maxX)
+	})
+	return nil
+}
+
+// completeStatus completes a partial status from the notebook's statuses.
+func (t *tui) completeStatus(partial string) string {
+	for _, status := range t.nb.Statuses() {
+		if strings.HasPrefix(status, partial) {
+			return status
+		}
+	}
+	return partial
+}
+
+func (t *tui) filterStatus(g *gotui.Gui, v *gotui.View) error {
+	if t.modalOpen {
+		if t.editorOpen {
+			g.CurrentView().EditWrite('F')
+		}
+		return nil
+	}
+	title := fmt.Sprintf("Show only status (%s, or blank for all)", strings.Join(t.nb.Statuses(), ", "))
+	return t.prompt(g, title, "", t.completeStatus, func(gg *gotui.Gui, status string) error {
+		t.tagFilter = ""
+		t.queryFilter = ""
+		if status == "" {
+			t.setFilter("", nil)
+		} else {
+			t.setFilter("status "+status, func(c notebook.Card) bool {
+				return c.Status == status
+			})
+		}
+		maxX, _ := gg.Size()
+		if err := t.setTitle(gg); err != nil {
+			return err
+		}
+		return t.drawCards(gg, maxX)
+	})
+}
@@ -0,0 +1,64 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/makyo/gotui"
+)
+
+func (t *tui) showTrash(g *gotui.Gui, v *gotui.View) error {
+	if t.modalOpen {
+		if t.editorOpen {
+			g.CurrentView().EditWrite('T')
+		}
+		return nil
+	}
+	trash := t.nb.Trash()
+	if len(trash) == 0 {
+		t.createModal("Trash", "The trash is empty.")
+		return nil
+	}
+
+	// Most recently deleted first.
+	items := []string{}
+	for i := len(trash) - 1; i >= 0; i-- {
+		count := descendants(trash[i].Card)
+		children := ""
+		if count > 0 {
+			children = fmt.Sprintf(" (+%d)", count)
+		}
+		items = append(items, fmt.Sprintf("%s  %s%s", trash[i].Deleted.Format("2006-01-02 15:04"), trash[i].Card.Title, children))
+	}
+	return t.showList(g, "trash", "Trash", items, " Restore: enter | Empty: E | Close: Q ")
+}
+
+func (t *tui) restoreTrash(g *gotui.Gui, v *gotui.View) error {
+	trash := t.nb.Trash()
+	selected := len(trash) - 1 - listSelected(v)
+	if err := t.closeList(g, v); err != nil {
+		return err
+	}
+	if selected < 0 {
+		return nil
+	}
+	maxX, _ := g.Size()
+	t.nb.Restore(trash[selected].Card.ID)
+	return t.drawCards(g, maxX)
+}
+
+func (t *tui) emptyTrash(g *gotui.Gui, v *gotui.View) error {
+	count := len(t.nb.Trash())
+	if err := t.closeList(g, v); err != nil {
+		return err
+	}
+	cards := "cards"
+	if count == 1 {
+		cards = "card"
+	}
+	return t.confirm(g, " Empty trash ", fmt.Sprintf("Permanently remove %d deleted %s?", count, cards),
+		func(gg *gotui.Gui) error {
+			t.nb.EmptyTrash()
+			return nil
+		},
+		confirmNoop)
+}
@@ -3,7 +3,10 @@ package ui
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/makyo/ansigo"
 	"github.com/makyo/gotui"
@@ -11,6 +14,9 @@ import (
 	"github.com/makyo/mandelnote/notebook"
 )
 
+// DefaultAutosave is how often unsaved changes are written to a recovery file unless otherwise configured.
+const DefaultAutosave = 30 * time.Second
+
 var (
 	columns    int = 12
 	confirmMsg     = fmt.Sprintf(" Confirm: %ses/%so ", ansigo.MaybeApplyWithReset("underline", "Y"), ansigo.MaybeApplyWithReset("underline", "N"))
@@ -32,6 +38,18 @@ type tui struct {
 	inputs        map[string]string
 	confirmYesFn  func(*gotui.Gui) error
 	confirmNoFn   func(*gotui.Gui) error
+
+	inputCompleteFn func(string) string
+	inputDoneFn     func(*gotui.Gui, string) error
+	form            *form
+	matches         []notebook.Match
+
+	filter            func(notebook.Card) bool
+	filterDescription string
+	tagFilter         string
+	queryFilter       string
+
+	autosaveInterval time.Duration
 }
 
 func (t *tui) onResize(g *gotui.Gui, x, y int) error {
@@ -54,12 +72,23 @@ func (t *tui) setTitle(g *gotui.Gui) error {
 	if v, err := g.SetView("title", -1, 0, maxX+1, 1); err != nil {
 		return err
 	} else {
-		title := fmt.Sprintf("   %s ── %s  ", t.nb.Title, t.nb.Author)
+		words := wordCount(t.nb.Counts().Words)
+		if target := t.nb.Target(); target > 0 {
+			words += " ── " + progressBar(t.nb.Counts().Words, target)
+		}
+		title := fmt.Sprintf("   %s ── %s ── %s ── %s  ", t.nb.Title, t.nb.Author, filepath.Base(t.nb.File()), words)
+		if t.filterDescription != "" {
+			title = fmt.Sprintf("%s── showing %s  ", title, t.filterDescription)
+		}
 		helpMsg := "  Hit ? for help  "
+		padding := maxX - utf8.RuneCountInString(title) - len(helpMsg) + 3
+		if padding < 0 {
+			padding = 0
+		}
 		v.Clear()
-		fmt.Fprintf(v, ansigo.MaybeApplyWithReset("underline+8", fmt.Sprintf("%s%s%s",
+		fmt.Fprint(v, ansigo.MaybeApplyWithReset("underline+8", fmt.Sprintf("%s%s%s",
 			title,
-			strings.Repeat(" ", maxX-len(title)-len(helpMsg)+3),
+			strings.Repeat(" ", padding),
 			helpMsg)))
 	}
 	return nil
@@ -72,7 +101,34 @@ func (t *tui) editMetadata(g *gotui.Gui, v *gotui.View) error {
 		}
 		return nil
 	}
-	return nil
+	fields := []formField{
+		{label: "Title", value: t.nb.Title},
+		{label: "Author", value: t.nb.Author},
+		{label: "Description", value: t.nb.Description, multiline: true},
+		{label: "Statuses, in order", value: strings.Join(t.nb.Statuses(), " ")},
+		{label: "New revision"},
+	}
+	info := ansigo.MaybeApplyWithReset("underline", "Revisions") + "\n\n"
+	if len(t.nb.Revisions) == 0 {
+		info += "No revisions yet.\n"
+	}
+	for _, r := range t.nb.Revisions {
+		info += fmt.Sprintf("%s  %s\n", ansigo.MaybeApplyWithReset("cyan", r.Timestamp.Format("2006-01-02 15:04")), r.Message)
+	}
+	return t.showForm(g, "Notebook", fields, info, func(gg *gotui.Gui, values []string) error {
+		if values[0] != t.nb.Title || values[1] != t.nb.Author || values[2] != t.nb.Description {
+			t.nb.SetMetadata(values[0], values[1], values[2])
+		}
+		if statuses := strings.Fields(values[3]); strings.Join(statuses, " ") != strings.Join(t.nb.Statuses(), " ") {
+			if err := t.nb.SetStatuses(statuses); err != nil {
+				t.createModal("Notebook", fmt.Sprintf("Unable to set statuses: %v", err))
+			}
+		}
+		if values[4] != "" {
+			t.nb.AddRevision(values[4])
+		}
+		return t.setTitle(gg)
+	})
 }
 
 func (t *tui) save(g *gotui.Gui, v *gotui.View) error {
@@ -89,7 +145,36 @@ func (t *tui) saveAs(g *gotui.Gui, v *gotui.View) error {
 	if t.modalOpen {
 		return nil
 	}
-	return nil
+	return t.prompt(g, "Save as", t.nb.File(), completePath, func(gg *gotui.Gui, filename string) error {
+		if filename == "" {
+			return nil
+		}
+		if _, err := os.Stat(filename); err == nil && filename != t.nb.File() {
+			return t.confirm(gg, " Save as ", fmt.Sprintf("%s already exists. Would you like to replace it?", filename),
+				func(ggg *gotui.Gui) error {
+					return t.saveTo(ggg, filename)
+				},
+				confirmNoop)
+		}
+		return t.saveTo(gg, filename)
+	})
+}
+
+// saveTo saves the notebook to a new file, going back to the old one if that fails.
+func (t *tui) saveTo(g *gotui.Gui, filename string) error {
+	previous := t.nb.File()
+	t.nb.SetFile(filename)
+	if err := t.nb.Save(); err != nil {
+		t.nb.SetFile(previous)
+		t.createModal("Save as", fmt.Sprintf("Unable to save to %s: %v", filename, err))
+		return nil
+	}
+
+	// Any recovery file for the old file holds changes which have now been saved to the new one.
+	t.nb.SetFile(previous)
+	t.nb.DiscardRecovery()
+	t.nb.SetFile(filename)
+	return t.setTitle(g)
 }
 
 func (t *tui) quit(g *gotui.Gui, v *gotui.View) error {
@@ -97,45 +182,71 @@ func (t *tui) quit(g *gotui.Gui, v *gotui.View) error {
 		return nil
 	}
 	if t.nb.Dirty() {
-		maxX, maxY := g.Size()
-		if v, err := g.SetView("confirm", t.colWidth*2, maxY/2-2, maxX-t.colWidth*2, maxY/2+2); err != nil {
-			if err != gotui.ErrUnknownView {
-				return err
-			}
-			t.modalOpen = true
-			v.Frame = true
-			v.FrameFgColor = gotui.ColorCyan | gotui.AttrBold
-			v.TitleFgColor = gotui.AttrBold
-			v.Title = " Quit "
-			v.Wrap = true
-			v.WordWrap = true
-			fmt.Fprint(v, "You have unsaved changes. Would you like to save before quitting?")
-			g.SetCurrentView("confirm")
-		}
-		if v, err := g.SetView("confirmActions", maxX-t.colWidth*2-20, maxY/2+1, maxX-t.colWidth*2-2, maxY/2+3); err != nil {
-			if err != gotui.ErrUnknownView {
-				return err
-			}
-			v.Frame = false
-			fmt.Fprintf(v, confirmMsg)
-		}
-		g.SetCurrentView("confirm")
-		t.confirmYesFn = func(gg *gotui.Gui) error {
-			err := t.nb.Save()
-			if err != nil {
-				return err
-			}
-			return gotui.ErrQuit
-		}
-		t.confirmNoFn = func(gg *gotui.Gui) error {
-			return gotui.ErrQuit
-		}
-		return nil
+		return t.confirm(g, " Quit ", "You have unsaved changes. Would you like to save before quitting?",
+			func(gg *gotui.Gui) error {
+				err := t.nb.Save()
+				if err != nil {
+					return err
+				}
+				return gotui.ErrQuit
+			},
+			func(gg *gotui.Gui) error {
+				t.nb.DiscardRecovery()
+				return gotui.ErrQuit
+			})
 	} else {
 		return gotui.ErrQuit
 	}
 }
 
+// autosave periodically writes any unsaved changes to the notebook's recovery file until done is closed.
+func (t *tui) autosave(done <-chan struct{}) {
+	ticker := time.NewTicker(t.autosaveInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			t.g.Update(func(g *gotui.Gui) error {
+				if t.nb.Dirty() {
+					t.nb.SaveRecovery()
+				}
+				return nil
+			})
+		}
+	}
+}
+
+// offerRecovery asks whether to restore the notebook's recovery file if there is one newer than the notebook.
+func (t *tui) offerRecovery(g *gotui.Gui) error {
+	when, ok := t.nb.HasRecovery()
+	if !ok {
+		return nil
+	}
+	msg := fmt.Sprintf("Unsaved changes from %s were found. Would you like to restore them?",
+		when.Format("Jan 2 15:04"))
+	return t.confirm(g, " Recover ", msg,
+		func(gg *gotui.Gui) error {
+			maxX, _ := gg.Size()
+			if err := t.nb.Recover(); err != nil {
+				t.createModal("Recover", fmt.Sprintf("Unable to restore unsaved changes: %v", err))
+				return nil
+			}
+			return t.drawCards(gg, maxX)
+		},
+		func(gg *gotui.Gui) error {
+			t.nb.DiscardRecovery()
+			return nil
+		})
+}
+
+// SetAutosave sets how often unsaved changes are written to the notebook's recovery file. An interval of zero turns
+// autosaving off.
+func (t *tui) SetAutosave(interval time.Duration) {
+	t.autosaveInterval = interval
+}
+
 func (t *tui) keybindings(g *gotui.Gui) error {
 	// Notebook-wide keybindings
 	if err := g.SetKeybinding("", '?', gotui.ModNone, t.showHelp); err != nil {
@@ -153,6 +264,12 @@ func (t *tui) keybindings(g *gotui.Gui) error {
 	if err := g.SetKeybinding("", gotui.KeyCtrlQ, gotui.ModNone, t.quit); err != nil {
 		return err
 	}
+	if err := g.SetKeybinding("", '/', gotui.ModNone, t.search); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("", 'R', gotui.ModNone, t.replace); err != nil {
+		return err
+	}
 
 	// Card tasks
 	if err := g.SetKeybinding("", 'n', gotui.ModNone, t.newCard); err != nil {
@@ -173,12 +290,21 @@ func (t *tui) keybindings(g *gotui.Gui) error {
 	if err := g.SetKeybinding("", gotui.KeyCtrlW, gotui.ModNone, t.closeEditor); err != nil {
 		return err
 	}
+	if err := g.SetKeybinding("editor", gotui.KeyCtrlX, gotui.ModNone, t.split); err != nil {
+		return err
+	}
 	if err := g.SetKeybinding("", 'p', gotui.ModNone, t.promote); err != nil {
 		return err
 	}
 	if err := g.SetKeybinding("", 'P', gotui.ModNone, t.promoteAll); err != nil {
 		return err
 	}
+	if err := g.SetKeybinding("", 'i', gotui.ModNone, t.demote); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("", 'I', gotui.ModNone, t.demoteAll); err != nil {
+		return err
+	}
 	if err := g.SetKeybinding("", 'm', gotui.ModNone, t.mergeDown); err != nil {
 		return err
 	}
@@ -191,6 +317,36 @@ func (t *tui) keybindings(g *gotui.Gui) error {
 	if err := g.SetKeybinding("", 'u', gotui.ModNone, t.moveUp); err != nil {
 		return err
 	}
+	if err := g.SetKeybinding("", 'a', gotui.ModNone, t.advanceStatus); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("", 'A', gotui.ModNone, t.retreatStatus); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("", 'F', gotui.ModNone, t.filterStatus); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("", '#', gotui.ModNone, t.filterTags); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("", 'Q', gotui.ModNone, t.filterQuery); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("", 't', gotui.ModNone, t.setTarget); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("", 'x', gotui.ModNone, t.deleteCard); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("", 'T', gotui.ModNone, t.showTrash); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("", 'z', gotui.ModNone, t.undo); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("", 'Z', gotui.ModNone, t.redo); err != nil {
+		return err
+	}
 
 	// Card movement
 	if err := g.SetKeybinding("", gotui.KeyArrowUp, gotui.ModNone, t.cycleUp); err != nil {
@@ -217,6 +373,44 @@ func (t *tui) keybindings(g *gotui.Gui) error {
 		return err
 	}
 
+	// List tasks
+	for _, list := range []string{"trash", "results"} {
+		if err := g.SetKeybinding(list, gotui.KeyArrowUp, gotui.ModNone, t.listUp); err != nil {
+			return err
+		}
+		if err := g.SetKeybinding(list, gotui.KeyArrowDown, gotui.ModNone, t.listDown); err != nil {
+			return err
+		}
+		if err := g.SetKeybinding(list, 'q', gotui.ModNone, t.closeList); err != nil {
+			return err
+		}
+	}
+	if err := g.SetKeybinding("trash", gotui.KeyEnter, gotui.ModNone, t.restoreTrash); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("trash", 'E', gotui.ModNone, t.emptyTrash); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("results", gotui.KeyEnter, gotui.ModNone, t.gotoResult); err != nil {
+		return err
+	}
+
+	// Form tasks
+	if err := g.SetKeybinding("", gotui.KeyEsc, gotui.ModNone, t.cancelForm); err != nil {
+		return err
+	}
+
+	// Input tasks
+	if err := g.SetKeybinding("input", gotui.KeyEnter, gotui.ModNone, t.submitInput); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("input", gotui.KeyTab, gotui.ModNone, t.completeInput); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("input", gotui.KeyEsc, gotui.ModNone, t.cancelInput); err != nil {
+		return err
+	}
+
 	// Confirm tasks
 	if err := g.SetKeybinding("confirm", 'y', gotui.ModNone, t.confirmYes); err != nil {
 		return err
@@ -263,6 +457,13 @@ func (t *tui) Run() {
 		os.Exit(2)
 	}
 
+	t.g.Update(t.offerRecovery)
+	if t.autosaveInterval > 0 {
+		done := make(chan struct{})
+		defer close(done)
+		go t.autosave(done)
+	}
+
 	if err := t.g.MainLoop(); err != nil && err != gotui.ErrQuit {
 		fmt.Fprintf(os.Stderr, "error running mainloop: %v", err)
 		os.Exit(3)
@@ -271,6 +472,7 @@ func (t *tui) Run() {
 
 func New(n *notebook.Notebook) *tui {
 	return &tui{
-		nb: n,
+		nb:               n,
+		autosaveInterval: DefaultAutosave,
 	}
 }